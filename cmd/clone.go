@@ -3,18 +3,39 @@ package cmd
 import (
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/tejsvapandey1/docmake/internal/backend"
+	"github.com/tejsvapandey1/docmake/internal/config"
 	"github.com/tejsvapandey1/docmake/internal/detect"
 	"github.com/tejsvapandey1/docmake/internal/docker"
 	"github.com/tejsvapandey1/docmake/internal/generator"
 	"github.com/tejsvapandey1/docmake/internal/git"
+	"github.com/tejsvapandey1/docmake/internal/lint"
+	"github.com/tejsvapandey1/docmake/internal/registry"
+	"github.com/tejsvapandey1/docmake/internal/sbom"
 )
 
-var dockerHubUser string
-var dockerHubPass string
+var registryHost string
+var namespace string
+var registryUser string
+var registryPass string
+var passwordStdin bool
+var passwordFile string
+var minConfidence float64
+var publishServicePorts bool
+var seedData bool
+var noHarden []string
+var backendName string
+var imageTags []string
+var platforms []string
+var vulnDB string
+var vulnThreshold string
+var cloneSignKey string
+var cloneVerifyKey string
 
 var cloneCmd = &cobra.Command{
 	Use:   "clone <repo-url>",
@@ -22,6 +43,45 @@ var cloneCmd = &cobra.Command{
 	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		repoURL := args[0]
+		startedAt := time.Now().UTC()
+
+		hardening, err := generator.NewHardening(noHarden)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			fmt.Println("Error reading config:", err)
+			return
+		}
+		if backendName == "" {
+			backendName = cfg.Get("backend")
+		}
+		engine, err := backend.Select(backendName)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		fmt.Println("Container backend:", engine.Name)
+
+		if len(platforms) == 0 && cfg.Get("platform") != "" {
+			platforms = strings.Split(cfg.Get("platform"), ",")
+		}
+		for i, p := range platforms {
+			platforms[i] = strings.TrimSpace(p)
+			if parts := strings.Split(platforms[i], "/"); len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+				fmt.Printf("Error: invalid platform %q (want os/arch[/variant], e.g. linux/arm64)\n", p)
+				return
+			}
+		}
+
+		gate, err := newVulnGate(cfg)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
 
 		// 1. Clone repo
 		folderPath, err := git.CloneRepo(repoURL)
@@ -31,6 +91,21 @@ var cloneCmd = &cobra.Command{
 		}
 		fmt.Println("Repository cloned at:", folderPath)
 
+		// 1a. Identify the revision before docmake adds its own files
+		revision, err := git.Describe(folderPath, generator.GeneratedFiles...)
+		if err != nil {
+			fmt.Println("⚠️  Could not read git revision, tagging as latest:", err)
+		} else {
+			fmt.Printf("Revision: %s", revision.ShortSHA)
+			if revision.Branch != "" {
+				fmt.Printf(" on %s", revision.Branch)
+			}
+			if revision.Dirty {
+				fmt.Print(" (dirty)")
+			}
+			fmt.Println()
+		}
+
 		// 2. Detect tech stack
 		stack, err := detect.DetectStack(folderPath)
 		if err != nil {
@@ -54,6 +129,10 @@ var cloneCmd = &cobra.Command{
 		case "go":
 			meta.EntryFile = "main.go"
 			meta.Port = "8080"
+		case "rust":
+			meta = detect.DetectRustDetails(folderPath)
+		case "java-maven":
+			meta = detect.DetectMavenDetails(folderPath)
 		}
 
 		fmt.Println("Entry File:", meta.EntryFile)
@@ -66,94 +145,383 @@ var cloneCmd = &cobra.Command{
 		envMap, envPath := detect.DetectEnv(folderPath)
 		meta.Env = envMap
 		meta.EnvFilePath = envPath
+		meta.Secrets = detect.ClassifyEnv(meta.Env)
 
 		if len(meta.Env) > 0 {
 			fmt.Println("Detected .env keys:")
-			for k := range meta.Env {
+			for _, k := range detect.SortedKeys(meta.Env) {
+				if kind, ok := meta.Secrets[k]; ok {
+					fmt.Printf(" - %s (secret: %s)\n", k, kind)
+					continue
+				}
 				fmt.Println(" -", k)
 			}
 		}
 
-		// 5. Detect Database
-		meta.Database = detect.DetectDatabase(folderPath)
-		if meta.Database.Type != "" {
-			fmt.Println("Detected Database:", meta.Database.Type)
+		// 5. Detect backing services
+		var skipped []detect.Dependency
+		meta.Dependencies, skipped = detect.FilterByConfidence(detect.DetectDependencies(folderPath), minConfidence)
+		for _, dep := range meta.Dependencies {
+			fmt.Printf("Detected Service: %s (confidence %.0f%%)\n", dep.Type, dep.Confidence*100)
+			printEvidence(dep)
+		}
+		for _, dep := range skipped {
+			fmt.Printf("Skipped Service: %s (confidence %.0f%% below %.0f%%)\n", dep.Type, dep.Confidence*100, minConfidence*100)
+			printEvidence(dep)
+		}
+
+		// 5b. Detect migration tooling
+		meta.Migrations = detect.DetectMigrations(folderPath)
+		if meta.Migrations.Tool != "" {
+			fmt.Printf("Migrations: %s (%s)\n", meta.Migrations.Tool, meta.Migrations.Evidence)
+		}
+		if meta.Migrations.SeedTool != "" || len(meta.Migrations.SeedFiles) > 0 {
+			fmt.Println("Seed data found (use --seed to load it)")
+		}
+
+		// 5c. Detect HTTP health endpoint
+		meta.HealthPath = detect.DetectHealthEndpoint(folderPath)
+		if meta.HealthPath != "" {
+			fmt.Println("Health endpoint:", meta.HealthPath)
+		}
+
+		// 5d. Detect on-disk state (SQLite, uploads)
+		meta.Persistence = detect.DetectPersistence(folderPath)
+		if meta.Persistence.SQLiteDriver != "" {
+			fmt.Println("SQLite driver:", meta.Persistence.SQLiteDriver)
+		}
+		for _, dir := range meta.Persistence.Dirs {
+			fmt.Println("Persistent directory:", dir)
+		}
+		for _, f := range meta.Persistence.RootFiles {
+			fmt.Printf("Persistent file: %s (linked into %s/, which gets the volume)\n", f, generator.RootDataDir)
+		}
+
+		// 5e. Detect OS packages needed by native language packages
+		meta.SystemPackages = detect.DetectSystemPackages(folderPath)
+		if len(meta.SystemPackages.Sources) > 0 {
+			fmt.Println("Native packages:", strings.Join(meta.SystemPackages.Sources, ", "))
+			if len(meta.SystemPackages.Build) > 0 {
+				fmt.Println(" - build:", strings.Join(meta.SystemPackages.Build, " "))
+			}
+			if len(meta.SystemPackages.Runtime) > 0 {
+				fmt.Println(" - runtime:", strings.Join(meta.SystemPackages.Runtime, " "))
+			}
+		}
+
+		// 5f. Detect workers and schedulers sharing the app image
+		meta.Processes = detect.DetectProcesses(folderPath)
+		for _, proc := range meta.Processes {
+			fmt.Printf("Process: %s (%s, from %s)\n", proc.Name, proc.Kind, proc.Evidence)
+			if proc.Broker != "" && !detect.HasDependency(meta.Dependencies, proc.Broker) {
+				fmt.Printf("Adding Service: %s (broker for %s)\n", proc.Broker, proc.Name)
+				meta.Dependencies = detect.RequireDependency(meta.Dependencies, proc.Broker, detect.Evidence{
+					File: proc.Evidence, Match: proc.Kind, Source: "process", Confidence: 1,
+				})
+			}
 		}
 
-		// 6. Get Docker Hub credentials (from flag or env)
-		if dockerHubUser == "" {
-			dockerHubUser = os.Getenv("DOCKERHUB_USERNAME")
+		// 6. Resolve the target registry and its credentials
+		if registryHost == "" {
+			registryHost = cfg.Get("registry")
+		}
+		if registryHost == "" {
+			registryHost = registry.DockerHub
 		}
-		if dockerHubPass == "" {
-			dockerHubPass = os.Getenv("DOCKERHUB_PASSWORD")
+		registryHost = strings.ToLower(strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(registryHost, "https://"), "http://"), "/"))
+		label := registry.Label(registryHost)
+
+		auth, freshCreds, err := resolveCredentials(registryHost, cfg)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
 		}
 
-		// If missing, ask interactively
-		if dockerHubUser == "" || dockerHubPass == "" {
-			fmt.Println("🔐 Docker Hub credentials required.")
-			dockerHubUser, dockerHubPass, err = docker.PromptForCredentials()
+		// 7. Create final image names: <registry>/<namespace>/<repo>:<tag>
+		if namespace == "" {
+			namespace = cfg.Get("namespace")
+		}
+		if namespace == "" {
+			namespace = strings.ToLower(auth.Username)
+		}
+		target, err := registry.NewTarget(registryHost, namespace)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		if len(imageTags) == 0 {
+			imageTags = strings.Split(cfg.Get("tags"), ",")
+			if cfg.Get("tags") == "" {
+				imageTags = strings.Split(registry.DefaultTags, ",")
+			}
+		}
+		tags, err := registry.GitTags(revision, imageTags)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+
+		var images []string
+		var imageRef registry.Reference
+		for i, tag := range tags {
+			ref, err := target.Image(filepath.Base(folderPath), tag)
 			if err != nil {
-				fmt.Println("Error reading credentials:", err)
+				fmt.Println("Error:", err)
 				return
 			}
+			if i == 0 {
+				imageRef = ref
+			}
+			images = append(images, ref.Familiar())
+		}
+		// Compose and the record refer to the first, most specific tag
+		imageName := images[0]
+		fmt.Println("Image:", imageName)
+		if len(images) > 1 {
+			fmt.Println("Tags:", strings.Join(tags, ", "))
 		}
-
-		// 7. Create final image name
-		imageName := fmt.Sprintf("%s/%s:latest", dockerHubUser, filepath.Base(folderPath))
 
 		// 8. Generate Dockerfile
-		err = generator.GenerateDockerfile(folderPath, stack, meta)
+		err = generator.GenerateDockerfile(folderPath, stack, meta, hardening)
 		if err != nil {
 			fmt.Println("Error creating Dockerfile:", err)
 			return
 		}
 		fmt.Println("📦 Dockerfile generated successfully!")
 
+		// 8a. Pin base images to the digests their tags point at today
+		if hardening.On(generator.RuleDigest) {
+			unpinned, err := generator.PinBaseImages(folderPath, docker.ResolveDigest)
+			if err != nil {
+				fmt.Println("Error pinning base images:", err)
+				return
+			}
+			for _, image := range unpinned {
+				fmt.Println("⚠️  Base image left unpinned:", image)
+			}
+		}
+
+		// 8b. Keep .env, VCS data and dependency dirs out of the build context
+		err = generator.GenerateDockerignore(folderPath, stack)
+		if err != nil {
+			fmt.Println("Error creating .dockerignore:", err)
+			return
+		}
+		fmt.Println("📦 .dockerignore generated successfully!")
+
 		// 9. Generate docker-compose.yml (using final image name)
-		err = generator.GenerateComposeFile(folderPath, stack, meta, imageName)
+		composeOpts := generator.ComposeOptions{
+			PublishServicePorts: publishServicePorts,
+			Seed:                seedData,
+			Hardening:           hardening,
+		}
+		err = generator.GenerateComposeFile(folderPath, stack, meta, imageName, composeOpts)
 		if err != nil {
 			fmt.Println("Error generating docker-compose file:", err)
 			return
 		}
 		fmt.Println("📦 docker-compose.yml generated successfully!")
+		if len(meta.Dependencies) > 0 {
+			fmt.Printf("🔑 Local service credentials written to %s (keep it out of git)\n", generator.LocalEnvFile)
+		}
 
-		// 10. Build image
-		err = docker.BuildImage(folderPath, imageName)
-		if err != nil {
-			fmt.Println("❌ Error building Docker image:", err)
-			return
+		if redacted := generator.RedactionSummary(meta); len(redacted) > 0 {
+			fmt.Printf("🔒 Redacted %d secret value(s) from docker-compose.yml:\n", len(redacted))
+			for _, line := range redacted {
+				fmt.Println(" -", line)
+			}
 		}
-		fmt.Println("🐳 Docker image built:", imageName)
 
-		// 11. Docker Hub login
-		err = docker.Login(dockerHubUser, dockerHubPass)
+		// 9b. Lint what was generated
+		findings, err := lint.Dir(folderPath, lint.Options{AppImage: imageName})
 		if err != nil {
-			fmt.Println("❌ Docker Hub login failed:", err)
+			fmt.Println("Error linting generated files:", err)
 			return
 		}
+		if len(findings) > 0 {
+			fmt.Printf("🔎 %d lint finding(s) (run \"docmake lint\" for details):\n", len(findings))
+			lint.WriteText(os.Stdout, findings)
+		}
 
-		// 12. Push image
-		err = docker.PushImage(imageName)
-		if err != nil {
-			fmt.Println("❌ Docker image push failed:", err)
+		// 10. Build image; multi-platform images are built while pushing.
+		// The vulnerability gate needs an image to read OS packages from,
+		// so one of their platforms is then built locally first.
+		buildArgs := generator.LabelArgs(repoURL, revision.Commit, revision.String(), startedAt)
+		scanPlatform := ""
+		if len(platforms) > 0 && gate.enabled() {
+			scanPlatform = hostPlatform(platforms)
+			fmt.Printf("🛡️  Building %s locally for the vulnerability gate\n", scanPlatform)
+		}
+		if len(platforms) == 0 || scanPlatform != "" {
+			err = engine.Build(folderPath, scanPlatform, buildArgs, images...)
+			if err != nil {
+				fmt.Println("❌ Error building image:", err)
+				return
+			}
+			fmt.Println("🐳 Image built:", imageName)
+		}
+
+		// 10a. Inventory what went into the image. The OS packages of a
+		// multi-platform image are read per platform after the push; the
+		// gate checks those of the platform built locally.
+		components := sbom.FromRepo(folderPath)
+		scanned := components
+		if len(platforms) == 0 || scanPlatform != "" {
+			scanned = append(append([]sbom.Component{}, components...), osPackages(engine.Name, imageName, "")...)
+		}
+		if len(platforms) == 0 {
+			components = scanned
+		}
+
+		// 10b. Offline vulnerability gate against a local OSV mirror
+		if !gate.check(scanned) {
 			return
 		}
 
-		fmt.Println("📤 Docker image pushed successfully:", imageName)
+		// 11. Registry login, unless the credentials come from a previous one
+		if freshCreds {
+			err = engine.Login(auth)
+			if err != nil {
+				fmt.Printf("❌ %s login failed: %v\n", label, err)
+				return
+			}
+		}
+
+		// 12. Push every tag; they share one manifest digest
+		var digest string
+		if len(platforms) > 0 {
+			digest, err = engine.Publish(folderPath, platforms, images, buildArgs, auth)
+			if err != nil {
+				fmt.Println("❌ Multi-platform build failed:", err)
+				return
+			}
+		} else {
+			for _, image := range images {
+				d, err := engine.Push(image, auth)
+				if err != nil {
+					fmt.Println("❌ Docker image push failed:", err)
+					return
+				}
+				if digest == "" {
+					digest = d
+				} else if d != "" && d != digest {
+					fmt.Printf("⚠️  %s pushed with a different digest: %s\n", image, d)
+				}
+			}
+		}
+
+		fmt.Println("📤 Docker image pushed successfully:", strings.Join(images, ", "))
+
+		// 12a. Record the digest and run exactly that image locally
+		record := generator.ImageRecord{
+			Image:     imageRef.WithTag("").Familiar(),
+			Tags:      tags,
+			Digest:    digest,
+			Commit:    revision.Commit,
+			Dirty:     revision.Dirty,
+			Platforms: platforms,
+			PushedAt:  time.Now().UTC(),
+		}
+		if digest != "" {
+			fmt.Println("   digest:", digest)
+
+			record.Pinned = imageRef.WithDigest(digest).Familiar()
+			if err := generator.PinComposeImage(folderPath, imageName, record.Pinned); err != nil {
+				fmt.Println("⚠️  Could not pin docker-compose.yml to the digest:", err)
+			} else {
+				fmt.Println("📌 docker-compose.yml pinned to", record.Pinned)
+			}
 
-		// 13. Auto-start the project locally
-		fmt.Println("🚀 Starting project locally using docker compose...")
-		composeCmd := exec.Command("docker", "compose", "up", "-d")
-		composeCmd.Dir = folderPath
-		composeCmd.Stdout = os.Stdout
-		composeCmd.Stderr = os.Stderr
+			recordPath, err := generator.WriteImageRecord(folderPath, record)
+			if err != nil {
+				fmt.Println("⚠️  Could not record the pushed image:", err)
+			} else {
+				fmt.Println("📝 Image record written to", recordPath)
+			}
+		} else {
+			fmt.Println("⚠️  Registry reported no digest; docker-compose.yml keeps the tag")
+		}
 
-		err = composeCmd.Run()
+		// 12b. Sign the pushed digest when a signing key is configured
+		if cloneSignKey == "" {
+			cloneSignKey = cfg.Get("sign-key")
+		}
+		if cloneSignKey != "" {
+			if record.Pinned == "" {
+				fmt.Println("❌ Can't sign an image without a digest")
+				return
+			}
+			if err := signImage(record.Pinned, cloneSignKey, auth); err != nil {
+				fmt.Println("❌ Signing failed:", err)
+				return
+			}
+		}
+
+		// 12c. Software bill of materials, saved locally and attached to
+		// the image in registries with the referrers API; one per platform
+		// of a multi-platform image
+		writeSBOMs(folderPath, engine.Name, record, components, auth)
+
+		// 12d. Describe how the image was made
+		baseImages, _ := generator.BaseImages(folderPath)
+		provenancePath, err := generator.WriteProvenance(folderPath, generator.Provenance{
+			Builder: "docmake/" + engine.Name,
+			Source: generator.ProvenanceSource{
+				URL:     generator.PublicURL(repoURL),
+				Commit:  revision.Commit,
+				Branch:  revision.Branch,
+				Version: revision.String(),
+				Dirty:   revision.Dirty,
+			},
+			Parameters: map[string]string{
+				"registry":  registryHost,
+				"platforms": strings.Join(platforms, ","),
+				"no-harden": strings.Join(noHarden, ","),
+			},
+			BaseImages: baseImages,
+			Detected:   generator.NewProvenanceDetect(stack, meta),
+			Output:     record,
+			StartedAt:  startedAt,
+			FinishedAt: time.Now().UTC(),
+		})
+		if err != nil {
+			fmt.Println("⚠️  Could not write provenance:", err)
+		} else {
+			fmt.Println("📝 Provenance written to", provenancePath)
+		}
+
+		// 13. Only run images signed with a trusted key, if one is set
+		if cloneVerifyKey == "" {
+			cloneVerifyKey = cfg.Get("verify-key")
+		}
+		if cloneVerifyKey != "" {
+			if record.Pinned == "" {
+				fmt.Println("❌ Can't verify an image without a digest; not starting it")
+				return
+			}
+			if err := verifyImage(record.Pinned, cloneVerifyKey, cfg); err != nil {
+				fmt.Println("❌ Signature check failed; not starting the project:", err)
+				return
+			}
+		}
+
+		// 14. Auto-start the project locally
+		fmt.Printf("🚀 Starting project locally using %s...\n", engine.Name)
+		err = engine.Up(folderPath)
 		if err != nil {
 			fmt.Println("❌ Failed to start project:", err)
 			return
 		}
 
+		// 14a. Load seed data once; compose up leaves the seed service out
+		if generator.HasSeedService(meta, composeOpts) {
+			fmt.Println("🌱 Loading seed data...")
+			if err := engine.RunOnce(folderPath, "seed"); err != nil {
+				fmt.Println("❌ Seeding failed:", err)
+				return
+			}
+		}
+
 		fmt.Println("🎉 Project is now running locally!")
 	},
 }
@@ -161,6 +529,41 @@ var cloneCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(cloneCmd)
 
-	cloneCmd.Flags().StringVar(&dockerHubUser, "hub-user", "", "Docker Hub username")
-	cloneCmd.Flags().StringVar(&dockerHubPass, "hub-pass", "", "Docker Hub password or token")
+	cloneCmd.Flags().StringVar(&registryHost, "registry", "", "Registry host, e.g. ghcr.io, harbor.example.com, localhost:5000 (default docker.io)")
+	cloneCmd.Flags().StringVar(&namespace, "namespace", "", "Registry namespace: user, organisation or project (default: registry user)")
+	cloneCmd.Flags().StringVar(&registryUser, "registry-user", "", "Registry username")
+	cloneCmd.Flags().StringVar(&registryPass, "registry-pass", "", "Registry password or token (insecure: visible in process listings, prefer --password-stdin)")
+	cloneCmd.Flags().BoolVar(&passwordStdin, "password-stdin", false, "Read the registry password or token from stdin")
+	cloneCmd.Flags().StringVar(&passwordFile, "password-file", "", "Read the registry password or token from a file")
+
+	// Docker Hub-era names, kept so existing scripts keep working
+	cloneCmd.Flags().StringVar(&registryUser, "hub-user", "", "Docker Hub username")
+	cloneCmd.Flags().StringVar(&registryPass, "hub-pass", "", "Docker Hub password or token")
+	cloneCmd.Flags().MarkDeprecated("hub-user", "use --registry-user")
+	cloneCmd.Flags().MarkDeprecated("hub-pass", "use --registry-pass")
+	cloneCmd.Flags().StringSliceVar(&imageTags, "tags", nil, "Tags to push, from: sha, branch, semver, latest (default from config, else sha,branch,semver,latest)")
+	cloneCmd.Flags().StringSliceVar(&platforms, "platform", nil, "Build a multi-platform image index, e.g. linux/amd64,linux/arm64")
+	cloneCmd.Flags().StringVar(&vulnDB, "vuln-db", "", "Directory of OSV advisories (JSON files or all.zip dumps) to check the image against before pushing")
+	cloneCmd.Flags().StringVar(&vulnThreshold, "vuln-threshold", "", "Lowest severity that blocks the push: low, medium, high, critical or none (default from config, else critical)")
+	cloneCmd.Flags().StringVar(&cloneSignKey, "sign-key", "", "Sign the pushed image with this private key (default from config sign-key)")
+	cloneCmd.Flags().StringVar(&cloneVerifyKey, "verify-key", "", "Only start the project if the image is signed by this public key (default from config verify-key)")
+	cloneCmd.Flags().BoolVar(&publishServicePorts, "publish-service-ports", false, "Publish database and broker ports on the host")
+	cloneCmd.Flags().BoolVar(&seedData, "seed", false, "Load seed data/fixtures found in the repo after migrations")
+	cloneCmd.Flags().StringVar(&backendName, "backend", "", "Container backend: docker, podman, buildah or auto (default from config, else auto)")
+	cloneCmd.Flags().StringSliceVar(&noHarden, "no-harden", nil, "Hardening rules to skip: user, slim, digest, no-new-privileges, cap-drop, read-only")
+	cloneCmd.Flags().Float64Var(&minConfidence, "min-confidence", detect.DefaultMinConfidence, "Minimum confidence (0-1) for a detected service to be added to compose")
+}
+
+// printEvidence lists where a dependency was found, capped to keep the
+// output readable on large repos
+func printEvidence(dep detect.Dependency) {
+	const maxShown = 3
+
+	for i, ev := range dep.Evidence {
+		if i == maxShown {
+			fmt.Printf("   ↳ ... and %d more\n", len(dep.Evidence)-maxShown)
+			break
+		}
+		fmt.Printf("   ↳ %s: %s (%s)\n", ev.File, ev.Match, ev.Source)
+	}
 }
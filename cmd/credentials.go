@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/tejsvapandey1/docmake/internal/config"
+	"github.com/tejsvapandey1/docmake/internal/docker"
+	"github.com/tejsvapandey1/docmake/internal/registry"
+)
+
+// resolveCredentials finds credentials for host, in order: --password-stdin
+// or --password-file, --registry-pass, the registry's environment
+// variables, ~/.docker/config.json and its credential helpers, and finally
+// an interactive prompt. fresh is false when the credentials were already
+// stored by a previous login, so there is nothing to verify.
+func resolveCredentials(host string, cfg config.Config) (auth docker.AuthConfig, fresh bool, err error) {
+	label := registry.Label(host)
+	auth.ServerAddress = registry.ServerAddress(host)
+	auth.Username = registryUser
+
+	switch {
+	case passwordStdin && passwordFile != "":
+		return auth, false, fmt.Errorf("--password-stdin and --password-file are mutually exclusive")
+	case passwordStdin:
+		auth.Password, err = readSecret(os.Stdin)
+	case passwordFile != "":
+		var f *os.File
+		if f, err = os.Open(passwordFile); err == nil {
+			auth.Password, err = readSecret(f)
+			f.Close()
+		}
+	case registryPass != "":
+		fmt.Println("⚠️  Passing a password on the command line is insecure (it shows up in process listings and shell history); prefer --password-stdin or --password-file.")
+		auth.Password = registryPass
+	}
+	if err != nil {
+		return auth, false, fmt.Errorf("reading password: %w", err)
+	}
+	if auth.Password != "" {
+		if auth.Username == "" {
+			return auth, false, fmt.Errorf("a password was given without --registry-user")
+		}
+		return auth, true, nil
+	}
+
+	if creds := registry.EnvCredentials(host, cfg.Get); !creds.Empty() && (registryUser == "" || registryUser == creds.Username) {
+		fmt.Printf("🔐 Using %s credentials from %s\n", label, creds.Source)
+		auth.Username, auth.Password = creds.Username, creds.Password
+		return auth, true, nil
+	}
+
+	stored, source, ok, err := docker.StoredAuth(auth.ServerAddress)
+	if err != nil {
+		fmt.Println("⚠️  Could not read stored credentials:", err)
+	}
+	if ok && (registryUser == "" || stored.Username == "" || registryUser == stored.Username) {
+		fmt.Printf("🔐 Using %s credentials from %s\n", label, source)
+		if stored.Username == "" {
+			stored.Username = registryUser
+		}
+		return stored, false, nil
+	}
+
+	// Nothing usable: ask interactively (local registries need none)
+	if !registry.NeedsAuth(host) {
+		return auth, false, nil
+	}
+	fmt.Printf("🔐 %s credentials required.\n", label)
+	auth.Username, auth.Password, err = docker.PromptForCredentials(label)
+	if err != nil {
+		return auth, false, fmt.Errorf("reading credentials: %w", err)
+	}
+	return auth, true, nil
+}
+
+// readSecret reads a password or token, dropping the trailing newline
+func readSecret(r io.Reader) (string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	secret := strings.TrimRight(string(data), "\r\n")
+	if secret == "" {
+		return "", fmt.Errorf("empty password")
+	}
+	return secret, nil
+}
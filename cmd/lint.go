@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/tejsvapandey1/docmake/internal/lint"
+)
+
+var lintFormat string
+var lintFailOn string
+var lintImage string
+
+var lintCmd = &cobra.Command{
+	Use:   "lint [dir]",
+	Short: "Check a Dockerfile and docker-compose.yml for common problems",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := "."
+		if len(args) == 1 {
+			dir = args[0]
+		}
+
+		switch lintFailOn {
+		case "info", "warning", "error", "none":
+		default:
+			fmt.Println("Error: unknown severity", lintFailOn)
+			os.Exit(2)
+		}
+
+		findings, err := lint.Dir(dir, lint.Options{AppImage: lintImage})
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(2)
+		}
+
+		switch lintFormat {
+		case "json":
+			lint.WriteJSON(os.Stdout, findings)
+		case "text":
+			lint.WriteText(os.Stdout, findings)
+			if len(findings) == 0 {
+				fmt.Println("✅ No lint findings")
+			}
+		default:
+			fmt.Println("Error: unknown format", lintFormat)
+			os.Exit(2)
+		}
+
+		if lintFailOn != "none" && lint.Failed(findings, lint.Severity(lintFailOn)) {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(lintCmd)
+
+	lintCmd.Flags().StringVar(&lintFormat, "format", "text", "Output format: text or json")
+	lintCmd.Flags().StringVar(&lintFailOn, "fail-on", "error", "Exit non-zero on findings of this severity or worse: info, warning, error, none")
+	lintCmd.Flags().StringVar(&lintImage, "image", "", "Image built from the Dockerfile, to match compose services against EXPOSE")
+}
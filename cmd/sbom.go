@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/tejsvapandey1/docmake/internal/docker"
+	"github.com/tejsvapandey1/docmake/internal/generator"
+	"github.com/tejsvapandey1/docmake/internal/registry"
+	"github.com/tejsvapandey1/docmake/internal/sbom"
+)
+
+// osPackages lists the OS packages of image for platform ("" for the
+// host's), warning instead of failing: an SBOM without them is still
+// worth having
+func osPackages(backendName, image, platform string) []sbom.Component {
+	// Buildah can't create containers; podman reads the same storage
+	tool := backendName
+	if tool == "buildah" {
+		tool = "podman"
+	}
+
+	comps, err := sbom.OSPackages(tool, image, platform)
+	if err != nil {
+		fmt.Println("⚠️  Could not list OS packages of the image:", err)
+	}
+	return comps
+}
+
+// writeSBOMs saves and attaches the SBOM of the pushed image. An image
+// index gets one per platform manifest, since every platform has its own
+// OS packages; comps then holds the language packages they share.
+func writeSBOMs(folderPath, backendName string, record generator.ImageRecord, comps []sbom.Component, auth docker.AuthConfig) {
+	img := sbom.Image{Name: record.Image, Digest: record.Digest}
+	if len(record.Tags) > 0 {
+		img.Tag = record.Tags[0]
+	}
+
+	if len(record.Platforms) == 0 || record.Pinned == "" {
+		writeSBOM(folderPath, img, "sbom", comps, auth)
+		return
+	}
+
+	manifests, err := docker.PlatformManifests(record.Pinned, auth)
+	if err != nil {
+		fmt.Println("⚠️  Could not list the platforms of the image index:", err)
+		return
+	}
+	ref, err := registry.ParseReference(record.Image)
+	if err != nil {
+		fmt.Println("⚠️  Could not write per-platform SBOMs:", err)
+		return
+	}
+
+	for _, m := range manifests {
+		platformImg := img
+		platformImg.Digest, platformImg.Platform = m.Digest, m.Platform
+
+		platformComps := append(append([]sbom.Component{}, comps...), osPackages(backendName, ref.WithDigest(m.Digest).Familiar(), m.Platform)...)
+		writeSBOM(folderPath, platformImg, "sbom-"+strings.ReplaceAll(m.Platform, "/", "-"), platformComps, auth)
+	}
+}
+
+// writeSBOM saves SPDX and CycloneDX documents for img as <name>.*.json
+// and attaches them to it as OCI referrers
+func writeSBOM(folderPath string, img sbom.Image, name string, comps []sbom.Component, auth docker.AuthConfig) {
+	docs, err := sbom.Generate(img, comps)
+	if err != nil {
+		fmt.Println("⚠️  Could not generate SBOM:", err)
+		return
+	}
+	paths, err := docs.Write(filepath.Join(folderPath, generator.StateDir), name)
+	if err != nil {
+		fmt.Println("⚠️  Could not write SBOM:", err)
+		return
+	}
+	if img.Platform != "" {
+		fmt.Printf("📋 SBOM for %s (%s) written to %s\n", img.Platform, sbom.Summary(comps), filepath.Dir(paths[0]))
+	} else {
+		fmt.Printf("📋 SBOM (%s) written to %s\n", sbom.Summary(comps), filepath.Dir(paths[0]))
+	}
+
+	if img.Digest == "" {
+		return
+	}
+	ref, err := registry.ParseReference(img.Name)
+	if err != nil {
+		fmt.Println("⚠️  Could not attach SBOM:", err)
+		return
+	}
+	subject := ref.WithTag("").WithDigest(img.Digest).String()
+
+	artifacts := []docker.Artifact{
+		{ArtifactType: sbom.SPDXMediaType, MediaType: sbom.SPDXMediaType, Filename: filepath.Base(paths[0]), Data: docs.SPDX},
+		{ArtifactType: sbom.CycloneDXMediaType, MediaType: sbom.CycloneDXMediaType, Filename: filepath.Base(paths[1]), Data: docs.CycloneDX},
+	}
+	for _, a := range artifacts {
+		digest, err := docker.AttachArtifact(subject, a, auth)
+		if errors.Is(err, docker.ErrReferrersUnsupported) {
+			fmt.Println("ℹ️  Registry has no OCI referrers API; SBOM kept locally only")
+			return
+		}
+		if err != nil {
+			fmt.Printf("⚠️  Could not attach %s: %v\n", a.Filename, err)
+			continue
+		}
+		fmt.Printf("📎 Attached %s to the image (%s)\n", a.Filename, digest)
+	}
+}
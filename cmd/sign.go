@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/tejsvapandey1/docmake/internal/config"
+	"github.com/tejsvapandey1/docmake/internal/docker"
+	"github.com/tejsvapandey1/docmake/internal/generator"
+	"github.com/tejsvapandey1/docmake/internal/registry"
+	"github.com/tejsvapandey1/docmake/internal/signing"
+)
+
+var signKey string
+var generateKey bool
+
+var signCmd = &cobra.Command{
+	Use:   "sign [image]",
+	Short: "Sign a pushed image with a local key (cosign-compatible)",
+	Long: `Sign a pushed image's digest with a local ECDSA key. The signature is stored
+next to the image the way cosign stores it (tag sha256-<digest>.sig), so
+"docmake verify" and "cosign verify --key cosign.pub" both accept it.
+
+Without an image, the one recorded in .docmake/image.json is signed.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.Load()
+		if err != nil {
+			fmt.Println("Error reading config:", err)
+			os.Exit(1)
+		}
+		keyPath := keyFile(signKey, cfg.Get("sign-key"), signing.PrivateKeyFile)
+
+		if generateKey {
+			pubPath, err := signing.GenerateKeyPair(keyPath)
+			if err != nil {
+				fmt.Println("Error:", err)
+				os.Exit(1)
+			}
+			fmt.Println("🔑 Private key written to", keyPath)
+			fmt.Println("🔑 Public key written to", pubPath, "(share it with whoever verifies)")
+			if len(args) == 0 {
+				return
+			}
+		}
+
+		image, err := imageArg(args)
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		ref, err := registry.ParseReference(image)
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		auth, _, err := resolveCredentials(ref.Registry, cfg)
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+
+		if err := signImage(image, keyPath, auth); err != nil {
+			fmt.Println("❌ Signing failed:", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// signImage signs the digest image points at with the private key at
+// keyPath and pushes the signature to the image's repository
+func signImage(image, keyPath string, auth docker.AuthConfig) error {
+	key, err := signing.LoadPrivateKey(keyPath)
+	if err != nil {
+		return err
+	}
+	ref, err := docker.PinnedReference(image, auth)
+	if err != nil {
+		return err
+	}
+
+	payload, err := signing.NewPayload(ref.Name(), ref.Digest)
+	if err != nil {
+		return err
+	}
+	value, err := signing.Sign(key, payload)
+	if err != nil {
+		return err
+	}
+
+	digest, err := docker.PushSignature(ref.String(), docker.Signature{Payload: payload, Value: value}, auth)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✍️  Signed %s (%s, signature %s)\n", ref.WithTag("").Familiar(), docker.SignatureTag(ref.Digest), digest)
+	return nil
+}
+
+// keyFile picks the flag, then the config value, then the file of that
+// name next to the config file
+func keyFile(flag, configured, name string) string {
+	if flag != "" {
+		return flag
+	}
+	if configured != "" {
+		return configured
+	}
+	return filepath.Join(filepath.Dir(config.Path()), name)
+}
+
+// imageArg is the image given on the command line, else the one pinned
+// in ./.docmake/image.json
+func imageArg(args []string) (string, error) {
+	if len(args) == 1 {
+		return args[0], nil
+	}
+	rec, err := generator.ReadImageRecord(".")
+	if err != nil {
+		return "", fmt.Errorf("no image given and no pushed image recorded here: %w", err)
+	}
+	if rec.Pinned == "" {
+		return "", fmt.Errorf("the recorded image has no digest")
+	}
+	return rec.Pinned, nil
+}
+
+func init() {
+	rootCmd.AddCommand(signCmd)
+
+	signCmd.Flags().StringVar(&signKey, "key", "", "Private key (default from config sign-key, else cosign.key next to the docmake config)")
+	signCmd.Flags().BoolVar(&generateKey, "generate-key", false, "Create a new key pair where --key points")
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tejsvapandey1/docmake/internal/config"
+	"github.com/tejsvapandey1/docmake/internal/docker"
+	"github.com/tejsvapandey1/docmake/internal/registry"
+	"github.com/tejsvapandey1/docmake/internal/signing"
+)
+
+var verifyKey string
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify [image...]",
+	Short: "Check that images carry a valid signature from a trusted key",
+	Long: `Check the cosign-compatible signatures of images against a public key.
+Exits non-zero unless every image has a valid signature, so a deploy can
+run "docmake verify && docker compose up".
+
+Without images, the one recorded in .docmake/image.json is checked.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.Load()
+		if err != nil {
+			fmt.Println("Error reading config:", err)
+			os.Exit(2)
+		}
+		keyPath := keyFile(verifyKey, cfg.Get("verify-key"), signing.PublicKeyFile)
+
+		images := args
+		if len(images) == 0 {
+			image, err := imageArg(nil)
+			if err != nil {
+				fmt.Println("Error:", err)
+				os.Exit(2)
+			}
+			images = []string{image}
+		}
+
+		failed := false
+		for _, image := range images {
+			if err := verifyImage(image, keyPath, cfg); err != nil {
+				fmt.Printf("❌ %s: %v\n", image, err)
+				failed = true
+			}
+		}
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+// verifyImage checks that the digest image points at has at least one
+// signature made with the public key at keyPath
+func verifyImage(image, keyPath string, cfg config.Config) error {
+	key, err := signing.LoadPublicKey(keyPath)
+	if err != nil {
+		return err
+	}
+	ref, err := registry.ParseReference(image)
+	if err != nil {
+		return err
+	}
+
+	auth := pullCredentials(ref.Registry, cfg)
+	if ref, err = docker.PinnedReference(image, auth); err != nil {
+		return err
+	}
+	sigs, err := docker.Signatures(ref.String(), auth)
+	if err != nil {
+		return err
+	}
+	if len(sigs) == 0 {
+		return fmt.Errorf("no signatures for %s", ref.Digest)
+	}
+
+	var reasons []string
+	for i, sig := range sigs {
+		_, err := signing.Verify(key, sig.Payload, sig.Value, ref.Name(), ref.Digest)
+		if err == nil {
+			fmt.Printf("✅ %s is signed (%s)\n", ref.WithTag("").Familiar(), keyPath)
+			return nil
+		}
+		reasons = append(reasons, fmt.Sprintf("  signature %d: %v", i+1, err))
+	}
+	return fmt.Errorf("none of %d signature(s) verify:\n%s", len(sigs), strings.Join(reasons, "\n"))
+}
+
+// pullCredentials finds read credentials without prompting: the
+// registry's environment variables, then stored Docker credentials. Public
+// images need none.
+func pullCredentials(host string, cfg config.Config) docker.AuthConfig {
+	auth := docker.AuthConfig{ServerAddress: registry.ServerAddress(host)}
+	if creds := registry.EnvCredentials(host, cfg.Get); !creds.Empty() {
+		auth.Username, auth.Password = creds.Username, creds.Password
+		return auth
+	}
+	if stored, _, ok, _ := docker.StoredAuth(auth.ServerAddress); ok {
+		return stored
+	}
+	return auth
+}
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+
+	verifyCmd.Flags().StringVar(&verifyKey, "key", "", "Public key (default from config verify-key, else cosign.pub next to the docmake config)")
+}
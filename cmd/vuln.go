@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/tejsvapandey1/docmake/internal/config"
+	"github.com/tejsvapandey1/docmake/internal/sbom"
+	"github.com/tejsvapandey1/docmake/internal/vuln"
+)
+
+// vulnGate holds the vulnerability policy of a clone: the OSV mirror
+// to check against and the lowest severity that blocks the push
+type vulnGate struct {
+	db        string
+	threshold vuln.Severity
+	block     bool
+}
+
+// newVulnGate reads --vuln-db and --vuln-threshold, falling back to the
+// config keys of the same name. The threshold defaults to critical;
+// "none" reports findings without blocking.
+func newVulnGate(cfg config.Config) (vulnGate, error) {
+	g := vulnGate{db: vulnDB, threshold: vuln.Critical, block: true}
+	if g.db == "" {
+		g.db = cfg.Get("vuln-db")
+	}
+
+	threshold := vulnThreshold
+	if threshold == "" {
+		threshold = cfg.Get("vuln-threshold")
+	}
+	switch threshold {
+	case "":
+	case "none":
+		g.block = false
+	default:
+		s, err := vuln.ParseSeverity(threshold)
+		if err != nil {
+			return g, fmt.Errorf("--vuln-threshold: %w", err)
+		}
+		g.threshold = s
+	}
+	return g, nil
+}
+
+// enabled reports whether a vulnerability database is configured
+func (g vulnGate) enabled() bool {
+	return g.db != ""
+}
+
+// check scans components offline and reports whether the push may go
+// ahead. Without a database there is nothing to check.
+func (g vulnGate) check(comps []sbom.Component) bool {
+	if !g.enabled() {
+		return true
+	}
+
+	db, err := vuln.Load(g.db)
+	if err != nil {
+		fmt.Println("❌ Could not load vulnerability database:", err)
+		return false
+	}
+
+	fmt.Printf("🛡️  Checking %d components against %d advisories in %s\n", len(comps), db.Count, g.db)
+
+	findings := vuln.Scan(db, comps)
+	if len(findings) == 0 {
+		fmt.Println("✅ No known vulnerabilities")
+		return true
+	}
+	fmt.Printf("⚠️  %d known vulnerabilities (%s):\n", len(findings), vuln.Counts(findings))
+	vuln.WriteText(os.Stdout, findings)
+
+	if blocking := vuln.Blocking(findings, g.threshold); g.block && len(blocking) > 0 {
+		fmt.Printf("❌ Push blocked: %d finding(s) at or above %s (--vuln-threshold)\n", len(blocking), g.threshold)
+		return false
+	}
+	return true
+}
+
+// hostPlatform picks the platform of a multi-platform build that the
+// host runs natively, or the first one when none matches
+func hostPlatform(platforms []string) string {
+	host := "linux/" + runtime.GOARCH
+	for _, p := range platforms {
+		if p == host || strings.HasPrefix(p, host+"/") {
+			return p
+		}
+	}
+	return platforms[0]
+}
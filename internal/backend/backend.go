@@ -0,0 +1,127 @@
+package backend
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/tejsvapandey1/docmake/internal/docker"
+)
+
+// Builder builds an image from the Dockerfile in dir with buildArgs,
+// tagged with every name in images. An empty platform builds for the
+// host.
+type Builder interface {
+	Build(dir, platform string, buildArgs map[string]string, images ...string) error
+}
+
+// Registry authenticates against and pushes to an image registry
+type Registry interface {
+	Login(auth docker.AuthConfig) error
+	// Push returns the manifest digest when the tool reports it
+	Push(image string, auth docker.AuthConfig) (string, error)
+}
+
+// Publisher builds images for several platforms and pushes them as one
+// OCI image index under every name in images, returning the index digest
+type Publisher interface {
+	Publish(dir string, platforms, images []string, buildArgs map[string]string, auth docker.AuthConfig) (string, error)
+}
+
+// Runner starts the generated compose project and runs its one-shot
+// services, such as seed, that compose up leaves out
+type Runner interface {
+	Up(dir string) error
+	RunOnce(dir, service string) error
+}
+
+// Backend bundles the tools of one container engine
+type Backend struct {
+	Name string
+	Builder
+	Registry
+	Publisher
+	Runner
+}
+
+// Names lists the selectable backends
+var Names = []string{"docker", "podman", "buildah"}
+
+// Select returns the backend called name. An empty name or "auto"
+// detects what is installed.
+func Select(name string) (*Backend, error) {
+	if name == "" || name == "auto" {
+		name = Detect()
+	}
+
+	switch name {
+	case "docker":
+		return &Backend{Name: name, Builder: dockerBuilder{}, Registry: dockerRegistry{}, Publisher: dockerPublisher{}, Runner: dockerRunner{}}, nil
+	case "podman":
+		return &Backend{Name: name, Builder: podmanBuilder{tool: "podman"}, Registry: podmanRegistry{tool: "podman"}, Publisher: podmanPublisher{tool: "podman"}, Runner: podmanRunner{}}, nil
+	case "buildah":
+		// Buildah builds and pushes but can't run containers
+		b := &Backend{Name: name, Builder: podmanBuilder{tool: "buildah"}, Registry: podmanRegistry{tool: "buildah"}, Publisher: podmanPublisher{tool: "buildah"}}
+		if available("podman") {
+			b.Runner = podmanRunner{}
+		} else {
+			b.Runner = dockerRunner{}
+		}
+		return b, nil
+	}
+
+	return nil, fmt.Errorf("unknown backend %q (valid: %s)", name, strings.Join(Names, ", "))
+}
+
+// Detect picks docker when its daemon is reachable, then podman, then
+// buildah, falling back to docker
+func Detect() string {
+	if available("docker") && dockerDaemonPresent() {
+		return "docker"
+	}
+	for _, tool := range []string{"podman", "buildah"} {
+		if available(tool) {
+			return tool
+		}
+	}
+	return "docker"
+}
+
+func dockerDaemonPresent() bool {
+	if os.Getenv("DOCKER_HOST") != "" {
+		return true
+	}
+	_, err := os.Stat("/var/run/docker.sock")
+	return err == nil
+}
+
+func available(tool string) bool {
+	_, err := exec.LookPath(tool)
+	return err == nil
+}
+
+// run executes a tool in dir with output streamed to the terminal
+func run(dir string, stdin string, name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	cmd.Dir = dir
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// checkContext refuses to build when secrets would enter the image
+func checkContext(dir string) error {
+	leaked, err := docker.SecretFilesInContext(dir)
+	if err != nil {
+		return fmt.Errorf("inspecting build context: %w", err)
+	}
+	if len(leaked) > 0 {
+		return fmt.Errorf("secret-looking files would be copied into the image (add them to .dockerignore): %s",
+			strings.Join(leaked, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package backend
+
+import (
+	"github.com/tejsvapandey1/docmake/internal/docker"
+)
+
+// ---------------------------------------------------
+// DOCKER
+// ---------------------------------------------------
+
+type dockerBuilder struct{}
+
+func (dockerBuilder) Build(dir, platform string, buildArgs map[string]string, images ...string) error {
+	if err := checkContext(dir); err != nil {
+		return err
+	}
+	return docker.BuildImage(dir, platform, buildArgs, images...)
+}
+
+type dockerRegistry struct{}
+
+func (dockerRegistry) Login(auth docker.AuthConfig) error {
+	return docker.Login(auth)
+}
+
+func (dockerRegistry) Push(image string, auth docker.AuthConfig) (string, error) {
+	return docker.PushImage(image, auth)
+}
+
+type dockerPublisher struct{}
+
+func (dockerPublisher) Publish(dir string, platforms, images []string, buildArgs map[string]string, auth docker.AuthConfig) (string, error) {
+	if err := checkContext(dir); err != nil {
+		return "", err
+	}
+	return docker.BuildxPublish(dir, platforms, images, buildArgs, auth)
+}
+
+type dockerRunner struct{}
+
+func (dockerRunner) Up(dir string) error {
+	if available("docker") {
+		return run(dir, "", "docker", "compose", "up", "-d")
+	}
+	return run(dir, "", "docker-compose", "up", "-d")
+}
+
+func (dockerRunner) RunOnce(dir, service string) error {
+	if available("docker") {
+		return run(dir, "", "docker", "compose", "run", "--rm", service)
+	}
+	return run(dir, "", "docker-compose", "run", "--rm", service)
+}
@@ -0,0 +1,487 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/tejsvapandey1/docmake/internal/detect"
+)
+
+// ---------------------------------------------------
+// COMPOSE -> KUBE (podman kube play)
+// ---------------------------------------------------
+
+// KubeFile is where Up writes the pod manifest derived from
+// docker-compose.yml, relative to the repo root. It holds the resolved
+// credentials, so it lives next to the compose secrets.
+const KubeFile = ".docmake/kube.yaml"
+
+// composeService is the part of a compose service a pod container needs
+type composeService struct {
+	Name        string
+	Image       string
+	Command     []string
+	Entrypoint  []string
+	Environment [][2]string // in file order
+	EnvFiles    []string
+	Ports       []string
+	Volumes     []string
+	Secrets     []string
+	Tmpfs       []string
+	ReadOnly    bool
+	CapDrop     []string
+	NoNewPrivs  bool
+	Profiles    bool
+}
+
+type composeProject struct {
+	Services []*composeService
+	Secrets  map[string]string // name -> file
+}
+
+// parseComposeFile reads the subset of compose syntax docmake generates:
+// block mappings and sequences, one-line flow sequences and folded (>)
+// scalars
+func parseComposeFile(content string) composeProject {
+	p := composeProject{Secrets: map[string]string{}}
+	var svc *composeService
+
+	section := ""
+	secretName := ""
+	svcIndent, keyIndent := -1, -1
+	key := ""
+	folded := []string(nil) // lines of a folded scalar being read
+
+	flushFolded := func() {
+		if svc == nil || folded == nil {
+			return
+		}
+		text := strings.Join(folded, " ")
+		switch key {
+		case "command":
+			svc.Command = splitCommand(text)
+		case "entrypoint":
+			svc.Entrypoint = splitCommand(text)
+		}
+		folded = nil
+	}
+
+	for _, raw := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(raw)
+		indent := len(raw) - len(strings.TrimLeft(raw, " "))
+
+		if folded != nil {
+			if trimmed == "" || indent > keyIndent {
+				if trimmed != "" {
+					folded = append(folded, trimmed)
+				}
+				continue
+			}
+			flushFolded()
+		}
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		if indent == 0 {
+			section, _ = cutKey(trimmed)
+			svcIndent, keyIndent, svc = -1, -1, nil
+			continue
+		}
+
+		switch section {
+		case "secrets":
+			k, v := cutKey(trimmed)
+			if v == "" && k != "file" {
+				secretName = k
+			} else if k == "file" && secretName != "" {
+				p.Secrets[secretName] = unquote(v)
+			}
+			continue
+		case "services":
+		default:
+			continue
+		}
+
+		if svcIndent < 0 {
+			svcIndent = indent
+		}
+		if indent == svcIndent {
+			name, _ := cutKey(trimmed)
+			svc = &composeService{Name: name}
+			p.Services = append(p.Services, svc)
+			keyIndent, key = -1, ""
+			continue
+		}
+		if svc == nil {
+			continue
+		}
+		if keyIndent < 0 {
+			keyIndent = indent
+		}
+
+		// Service-level key
+		if indent == keyIndent && !strings.HasPrefix(trimmed, "-") {
+			var v string
+			key, v = cutKey(trimmed)
+			switch {
+			case v == ">" || v == ">-":
+				folded = []string{}
+			case key == "image":
+				svc.Image = unquote(v)
+			case key == "command":
+				svc.Command = commandValue(v)
+			case key == "entrypoint":
+				svc.Entrypoint = commandValue(v)
+			case key == "read_only":
+				svc.ReadOnly = v == "true"
+			case key == "profiles":
+				svc.Profiles = true
+			case strings.HasPrefix(v, "["):
+				for _, item := range flowItems(v) {
+					svc.addItem(key, item)
+				}
+			}
+			continue
+		}
+
+		// Nested content of the current key
+		if item, ok := strings.CutPrefix(trimmed, "- "); ok {
+			svc.addItem(key, unquote(item))
+		} else if key == "environment" {
+			k, v := cutKey(trimmed)
+			svc.Environment = append(svc.Environment, [2]string{k, unquote(v)})
+		}
+	}
+	flushFolded()
+
+	return p
+}
+
+func (s *composeService) addItem(key, item string) {
+	switch key {
+	case "environment":
+		k, v, _ := strings.Cut(item, "=")
+		s.Environment = append(s.Environment, [2]string{k, v})
+	case "env_file":
+		s.EnvFiles = append(s.EnvFiles, item)
+	case "ports":
+		s.Ports = append(s.Ports, item)
+	case "volumes":
+		s.Volumes = append(s.Volumes, item)
+	case "secrets":
+		s.Secrets = append(s.Secrets, item)
+	case "tmpfs":
+		s.Tmpfs = append(s.Tmpfs, item)
+	case "cap_drop":
+		s.CapDrop = append(s.CapDrop, item)
+	case "security_opt":
+		if item == "no-new-privileges:true" || item == "no-new-privileges" {
+			s.NoNewPrivs = true
+		}
+	case "profiles":
+		s.Profiles = true
+	}
+}
+
+// cutKey splits "key: value"
+func cutKey(s string) (string, string) {
+	k, v, _ := strings.Cut(s, ":")
+	return strings.TrimSpace(k), strings.TrimSpace(v)
+}
+
+func unquote(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && (s[0] == '"' && s[len(s)-1] == '"' || s[0] == '\'' && s[len(s)-1] == '\'') {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// flowItems splits a one-line flow sequence; docmake writes them as JSON
+func flowItems(v string) []string {
+	var items []string
+	if json.Unmarshal([]byte(v), &items) == nil {
+		return items
+	}
+	for _, part := range strings.Split(strings.Trim(v, "[]"), ",") {
+		if part = unquote(part); part != "" {
+			items = append(items, part)
+		}
+	}
+	return items
+}
+
+// commandValue reads a command or entrypoint written as a flow sequence
+// or a plain string
+func commandValue(v string) []string {
+	if strings.HasPrefix(v, "[") {
+		return flowItems(v)
+	}
+	return splitCommand(unquote(v))
+}
+
+// splitCommand splits a string command into words the way compose does,
+// honouring single and double quotes
+func splitCommand(s string) []string {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+
+	for _, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words
+}
+
+// ---------------------------------------------------
+// POD MANIFEST
+// ---------------------------------------------------
+
+var interpolation = regexp.MustCompile(`\$\$|\$\{([A-Za-z_][A-Za-z0-9_]*)(?::?-([^}]*))?\}`)
+
+// interpolate resolves ${VAR} and ${VAR:-default} from the environment
+// and the project's .env like compose does, and unescapes $$
+func interpolate(s string, dotenv map[string]string) string {
+	return interpolation.ReplaceAllStringFunc(s, func(m string) string {
+		if m == "$$" {
+			return "$"
+		}
+		sub := interpolation.FindStringSubmatch(m)
+		if v, ok := os.LookupEnv(sub[1]); ok {
+			return v
+		}
+		if v, ok := dotenv[sub[1]]; ok {
+			return v
+		}
+		return sub[2]
+	})
+}
+
+var podNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// kubeManifest turns the compose project in dir into one pod. Containers
+// of a pod share localhost, so every service name is aliased to
+// 127.0.0.1 and connection URLs such as postgres:5432 keep working.
+// Kube has no depends_on: the pod restarts failed containers, so a
+// migration retries until its database accepts connections and the app
+// until the migration has run.
+func kubeManifest(dir string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "docker-compose.yml"))
+	if err != nil {
+		return "", err
+	}
+	project := parseComposeFile(string(data))
+	dotenv, _ := detect.ParseEnvFile(filepath.Join(dir, ".env"))
+
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	pod := strings.Trim(podNameChars.ReplaceAllString(strings.ToLower(filepath.Base(abs)), "-"), "-")
+	if pod == "" {
+		pod = "docmake"
+	}
+
+	var names []string
+	var containers, volumes strings.Builder
+	seenVolume := map[string]bool{}
+	addVolume := func(name, spec string) {
+		if !seenVolume[name] {
+			seenVolume[name] = true
+			volumes.WriteString(fmt.Sprintf("    - name: %s\n%s", name, spec))
+		}
+	}
+
+	for _, svc := range project.Services {
+		// compose up leaves out services behind a profile, such as seed
+		if svc.Profiles {
+			continue
+		}
+		names = append(names, svc.Name)
+
+		containers.WriteString(fmt.Sprintf("    - name: %s\n      image: %s\n", svc.Name, quoted(interpolate(svc.Image, dotenv))))
+		writeList(&containers, "command", svc.Entrypoint, dotenv)
+		writeList(&containers, "args", svc.Command, dotenv)
+
+		// environment wins over env_file, the later env_file over the earlier
+		env := map[string]string{}
+		var order []string
+		set := func(k, v string) {
+			if _, ok := env[k]; !ok {
+				order = append(order, k)
+			}
+			env[k] = v
+		}
+		for _, file := range svc.EnvFiles {
+			values, err := detect.ParseEnvFile(filepath.Join(dir, file))
+			if err != nil {
+				return "", fmt.Errorf("service %s: reading %s: %w", svc.Name, file, err)
+			}
+			for _, k := range detect.SortedKeys(values) {
+				set(k, values[k])
+			}
+		}
+		for _, kv := range svc.Environment {
+			set(kv[0], interpolate(kv[1], dotenv))
+		}
+		if len(order) > 0 {
+			containers.WriteString("      env:\n")
+			for _, k := range order {
+				containers.WriteString(fmt.Sprintf("        - name: %s\n          value: %s\n", quoted(k), quoted(env[k])))
+			}
+		}
+
+		if len(svc.Ports) > 0 {
+			containers.WriteString("      ports:\n")
+			for _, mapping := range svc.Ports {
+				parts := strings.Split(strings.SplitN(mapping, "/", 2)[0], ":")
+				host, container := parts[0], parts[len(parts)-1]
+				if len(parts) > 1 {
+					host = parts[len(parts)-2]
+				}
+				containers.WriteString(fmt.Sprintf("        - containerPort: %s\n          hostPort: %s\n", container, host))
+			}
+		}
+
+		var mounts strings.Builder
+		for _, v := range svc.Volumes {
+			parts := strings.Split(v, ":")
+			if len(parts) < 2 {
+				continue
+			}
+			source, target := parts[0], parts[1]
+			readOnly := len(parts) > 2 && parts[2] == "ro"
+
+			var name string
+			if strings.HasPrefix(source, ".") || strings.HasPrefix(source, "/") {
+				// Bind mount of a repo directory
+				path := source
+				if !filepath.IsAbs(path) {
+					path = filepath.Join(abs, path)
+				}
+				name = svc.Name + "-" + podNameChars.ReplaceAllString(strings.ToLower(filepath.Base(path)), "-")
+				addVolume(name, fmt.Sprintf("      hostPath:\n        path: %s\n", quoted(path)))
+			} else {
+				// Named volume: podman creates it for the claim
+				name = strings.ReplaceAll(source, "_", "-")
+				addVolume(name, fmt.Sprintf("      persistentVolumeClaim:\n        claimName: %s\n", source))
+			}
+			mounts.WriteString(fmt.Sprintf("        - name: %s\n          mountPath: %s\n", name, target))
+			if readOnly {
+				mounts.WriteString("          readOnly: true\n")
+			}
+		}
+		for _, secret := range svc.Secrets {
+			file, ok := project.Secrets[secret]
+			if !ok {
+				return "", fmt.Errorf("service %s: secret %s has no file", svc.Name, secret)
+			}
+			name := "secret-" + strings.ReplaceAll(secret, "_", "-")
+			addVolume(name, fmt.Sprintf("      hostPath:\n        path: %s\n        type: File\n", quoted(filepath.Join(abs, file))))
+			mounts.WriteString(fmt.Sprintf("        - name: %s\n          mountPath: /run/secrets/%s\n          readOnly: true\n", name, secret))
+		}
+		for i, t := range svc.Tmpfs {
+			// tmpfs options such as uid= have no emptyDir equivalent
+			target, _, _ := strings.Cut(t, ":")
+			name := fmt.Sprintf("%s-tmp-%d", svc.Name, i)
+			addVolume(name, "      emptyDir:\n        medium: Memory\n")
+			mounts.WriteString(fmt.Sprintf("        - name: %s\n          mountPath: %s\n", name, target))
+		}
+		if mounts.Len() > 0 {
+			containers.WriteString("      volumeMounts:\n" + mounts.String())
+		}
+
+		if svc.ReadOnly || svc.NoNewPrivs || len(svc.CapDrop) > 0 {
+			containers.WriteString("      securityContext:\n")
+			if svc.ReadOnly {
+				containers.WriteString("        readOnlyRootFilesystem: true\n")
+			}
+			if svc.NoNewPrivs {
+				containers.WriteString("        allowPrivilegeEscalation: false\n")
+			}
+			if len(svc.CapDrop) > 0 {
+				containers.WriteString("        capabilities:\n          drop:\n")
+				for _, c := range svc.CapDrop {
+					containers.WriteString(fmt.Sprintf("            - %s\n", c))
+				}
+			}
+		}
+	}
+
+	if len(names) == 0 {
+		return "", fmt.Errorf("no services in docker-compose.yml")
+	}
+
+	var sb strings.Builder
+	sb.WriteString("# Generated by docmake from docker-compose.yml for podman kube play.\n")
+	sb.WriteString("# Holds resolved credentials: keep it out of version control.\n")
+	sb.WriteString(fmt.Sprintf("apiVersion: v1\nkind: Pod\nmetadata:\n  name: %s\nspec:\n  restartPolicy: OnFailure\n", pod))
+	sb.WriteString("  hostAliases:\n    - ip: 127.0.0.1\n      hostnames:\n")
+	for _, name := range names {
+		sb.WriteString(fmt.Sprintf("        - %s\n", name))
+	}
+	sb.WriteString("  containers:\n" + containers.String())
+	if volumes.Len() > 0 {
+		sb.WriteString("  volumes:\n" + volumes.String())
+	}
+	return sb.String(), nil
+}
+
+func writeList(sb *strings.Builder, key string, items []string, dotenv map[string]string) {
+	if len(items) == 0 {
+		return
+	}
+	sb.WriteString(fmt.Sprintf("      %s:\n", key))
+	for _, item := range items {
+		sb.WriteString(fmt.Sprintf("        - %s\n", quoted(interpolate(item, dotenv))))
+	}
+}
+
+// quoted renders s as a double-quoted YAML scalar
+func quoted(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+// writeKubeFile writes the pod manifest for dir and returns its path
+func writeKubeFile(dir string) (string, error) {
+	manifest, err := kubeManifest(dir)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, KubeFile)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, []byte(manifest), 0600); err != nil {
+		return "", err
+	}
+	return path, os.Chmod(path, 0600)
+}
@@ -0,0 +1,162 @@
+package backend
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/tejsvapandey1/docmake/internal/docker"
+	"github.com/tejsvapandey1/docmake/internal/registry"
+)
+
+// ---------------------------------------------------
+// PODMAN + BUILDAH
+// ---------------------------------------------------
+
+// The two share their build and push command line, so one implementation
+// serves both; tool is "podman" or "buildah".
+
+type podmanBuilder struct {
+	tool string
+}
+
+func (b podmanBuilder) Build(dir, platform string, buildArgs map[string]string, images ...string) error {
+	if err := checkContext(dir); err != nil {
+		return err
+	}
+
+	fmt.Printf("🦭 Building image with %s: %s\n", b.tool, strings.Join(images, ", "))
+
+	// --layers keeps buildah's layer cache like podman and docker do
+	args := append([]string{"build", "--layers"}, docker.BuildArgFlags(buildArgs)...)
+	if platform != "" {
+		args = append(args, "--platform", platform)
+	}
+	for _, image := range images {
+		args = append(args, "-t", qualify(image))
+	}
+	return run(dir, "", b.tool, append(args, "-f", "Dockerfile", ".")...)
+}
+
+type podmanRegistry struct {
+	tool string
+}
+
+func (r podmanRegistry) Login(auth docker.AuthConfig) error {
+	if auth.Username == "" || auth.Password == "" {
+		fmt.Println("⚠️  Registry login skipped (credentials missing).")
+		return nil
+	}
+
+	host := docker.RegistryHost(auth.ServerAddress)
+	fmt.Printf("🔐 Logging into %s with %s...\n", registry.Label(host), r.tool)
+	return run("", auth.Password, r.tool, "login", "-u", auth.Username, "--password-stdin", host)
+}
+
+func (r podmanRegistry) Push(image string, auth docker.AuthConfig) (string, error) {
+	fmt.Println("📤 Pushing image:", image)
+
+	f, err := os.CreateTemp("", "docmake-digest-")
+	if err != nil {
+		return "", err
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	ref := qualify(image)
+	if err := run("", "", r.tool, "push", "--digestfile", f.Name(), ref, "docker://"+ref); err != nil {
+		return "", err
+	}
+
+	digest, _ := os.ReadFile(f.Name())
+	return strings.TrimSpace(string(digest)), nil
+}
+
+// podmanPublisher builds every platform into a local manifest list and
+// pushes it with all its images
+type podmanPublisher struct {
+	tool string
+}
+
+func (p podmanPublisher) Publish(dir string, platforms, images []string, buildArgs map[string]string, auth docker.AuthConfig) (string, error) {
+	if err := checkContext(dir); err != nil {
+		return "", err
+	}
+
+	// A leftover list from an earlier run would collect stale images
+	list := qualify(images[0])
+	exec.Command(p.tool, "manifest", "rm", list).Run()
+
+	fmt.Printf("🦭 Building %s for %s with %s\n", list, strings.Join(platforms, ", "), p.tool)
+	args := append([]string{"build", "--layers", "--platform", strings.Join(platforms, ",")}, docker.BuildArgFlags(buildArgs)...)
+	err := run(dir, "", p.tool, append(args, "--manifest", list, "-f", "Dockerfile", ".")...)
+	if err != nil {
+		return "", err
+	}
+
+	f, err := os.CreateTemp("", "docmake-digest-")
+	if err != nil {
+		return "", err
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	for _, image := range images {
+		fmt.Println("📤 Pushing image index:", image)
+		err := run("", "", p.tool, "manifest", "push", "--all", "--format", "oci",
+			"--digestfile", f.Name(), list, "docker://"+qualify(image))
+		if err != nil {
+			return "", err
+		}
+	}
+
+	digest, _ := os.ReadFile(f.Name())
+	return strings.TrimSpace(string(digest)), nil
+}
+
+type podmanRunner struct{}
+
+// Up prefers podman-compose, then "podman compose". Without a compose
+// provider the compose project is converted to a pod manifest and started
+// with podman kube play. A kube.yaml already in the repo describes some
+// other deployment, so it is never played in its place.
+func (podmanRunner) Up(dir string) error {
+	if available("podman-compose") {
+		return run(dir, "", "podman-compose", "up", "-d")
+	}
+	if exec.Command("podman", "compose", "version").Run() == nil {
+		return run(dir, "", "podman", "compose", "up", "-d")
+	}
+
+	manifest, err := writeKubeFile(dir)
+	if err != nil {
+		return fmt.Errorf("converting docker-compose.yml for podman kube play: %w", err)
+	}
+	fmt.Printf("🦭 No compose provider for podman, playing %s (stop it with: podman kube down %s)\n", KubeFile, KubeFile)
+	return run(dir, "", "podman", "kube", "play", "--replace", manifest)
+}
+
+func (podmanRunner) RunOnce(dir, service string) error {
+	if available("podman-compose") {
+		return run(dir, "", "podman-compose", "run", "--rm", service)
+	}
+	if exec.Command("podman", "compose", "version").Run() == nil {
+		return run(dir, "", "podman", "compose", "run", "--rm", service)
+	}
+	return fmt.Errorf("running %s needs a compose provider for podman: install podman-compose", service)
+}
+
+// ---------------------------------------------------
+// HELPERS
+// ---------------------------------------------------
+
+// qualify expands short names to fully qualified references: podman
+// would otherwise tag builds as localhost/<name> and refuse ambiguous pushes
+func qualify(image string) string {
+	ref, err := registry.ParseReference(image)
+	if err != nil {
+		return image
+	}
+	return ref.String()
+}
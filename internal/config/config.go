@@ -0,0 +1,68 @@
+package config
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Config holds user settings from $XDG_CONFIG_HOME/docmake/config
+// (default ~/.config/docmake/config). The file has one "key = value" per
+// line; "#" starts a comment. DOCMAKE_<KEY> environment variables win
+// over the file.
+type Config struct {
+	values map[string]string
+}
+
+// Path returns the location of the config file
+func Path() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "docmake", "config")
+}
+
+// Load reads the config file. A missing file yields an empty config.
+func Load() (Config, error) {
+	c := Config{values: map[string]string{}}
+
+	f, err := os.Open(Path())
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return c, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		c.values[strings.TrimSpace(key)] = strings.Trim(strings.TrimSpace(value), `"`)
+	}
+
+	return c, scanner.Err()
+}
+
+// Get returns a setting; DOCMAKE_<KEY> (dashes as underscores) overrides
+// the file
+func (c Config) Get(key string) string {
+	env := "DOCMAKE_" + strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+	if v := os.Getenv(env); v != "" {
+		return v
+	}
+	return c.values[key]
+}
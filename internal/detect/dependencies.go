@@ -0,0 +1,528 @@
+package detect
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Evidence records where a dependency was spotted
+type Evidence struct {
+	File       string
+	Match      string
+	Source     string // manifest, dev-manifest, import, env or process
+	Confidence float64
+}
+
+// Dependency is a backing service the app needs at runtime
+type Dependency struct {
+	Type       string
+	Port       string
+	EnvVar     string
+	DefaultURI string
+
+	// ExtraEnv holds further connection variables some client libraries
+	// read instead of EnvVar (e.g. CELERY_BROKER_URL)
+	ExtraEnv map[string]string
+
+	// Confidence combines all evidence, from 0 to 1
+	Confidence float64
+	Evidence   []Evidence
+}
+
+// Confidence contributed by one piece of evidence of each source
+const (
+	confidenceManifest    = 0.9
+	confidenceDevManifest = 0.4
+	confidenceImport      = 0.7
+	confidenceEnvURL      = 0.6
+)
+
+// DefaultMinConfidence is the threshold below which detected services are
+// not added to docker-compose.yml
+const DefaultMinConfidence = 0.5
+
+type dependencySpec struct {
+	Type       string
+	Port       string
+	EnvVar     string
+	DefaultURI string
+	ExtraEnv   map[string]string
+
+	Npm       []string
+	PyPI      []string
+	PyModules []string
+	GoModules []string
+	Maven     []string
+
+	// URL schemes that identify the service in .env values
+	Schemes []string
+}
+
+// dependencyCatalog lists the services docmake can stand up locally, in the
+// order they are emitted into docker-compose.yml
+var dependencyCatalog = []dependencySpec{
+	{
+		Type:       "mongo",
+		Port:       "27017",
+		EnvVar:     "MONGO_URI",
+		DefaultURI: "mongodb://mongo:27017",
+		Npm:        []string{"mongoose", "mongodb"},
+		PyPI:       []string{"pymongo", "motor", "mongoengine", "djongo"},
+		PyModules:  []string{"pymongo", "motor", "mongoengine"},
+		GoModules:  []string{"go.mongodb.org/mongo-driver"},
+		Maven:      []string{"mongodb-driver-sync", "mongodb-driver-reactivestreams", "spring-boot-starter-data-mongodb"},
+		Schemes:    []string{"mongodb", "mongodb+srv"},
+	},
+	{
+		Type:       "postgres",
+		Port:       "5432",
+		EnvVar:     "DATABASE_URL",
+		DefaultURI: "postgres://postgres:5432/postgres?sslmode=disable",
+		Npm:        []string{"pg", "pg-promise", "postgres", "@neondatabase/serverless"},
+		PyPI:       []string{"psycopg2", "psycopg2-binary", "psycopg", "psycopg-binary", "asyncpg"},
+		PyModules:  []string{"psycopg2", "psycopg", "asyncpg"},
+		GoModules:  []string{"github.com/lib/pq", "github.com/jackc/pgx", "gorm.io/driver/postgres"},
+		Maven:      []string{"postgresql", "r2dbc-postgresql"},
+		Schemes:    []string{"postgres", "postgresql"},
+	},
+	{
+		Type:       "mysql",
+		Port:       "3306",
+		EnvVar:     "MYSQL_URL",
+		DefaultURI: "mysql://mysql:3306/app",
+		Npm:        []string{"mysql", "mysql2"},
+		PyPI:       []string{"mysqlclient", "pymysql", "mysql-connector-python", "aiomysql"},
+		PyModules:  []string{"MySQLdb", "pymysql", "mysql.connector", "aiomysql"},
+		GoModules:  []string{"github.com/go-sql-driver/mysql", "gorm.io/driver/mysql"},
+		Maven:      []string{"mysql-connector-java", "mysql-connector-j"},
+		Schemes:    []string{"mysql"},
+	},
+	{
+		Type:       "redis",
+		Port:       "6379",
+		EnvVar:     "REDIS_URL",
+		DefaultURI: "redis://redis:6379",
+		Npm:        []string{"redis", "ioredis", "bull", "bullmq"},
+		PyPI:       []string{"redis", "aioredis", "rq", "django-redis"},
+		PyModules:  []string{"redis", "aioredis", "rq", "django_redis"},
+		GoModules:  []string{"github.com/redis/go-redis", "github.com/go-redis/redis", "github.com/gomodule/redigo"},
+		Maven:      []string{"jedis", "lettuce-core", "spring-boot-starter-data-redis"},
+		Schemes:    []string{"redis", "rediss"},
+	},
+	{
+		Type:       "rabbitmq",
+		Port:       "5672",
+		EnvVar:     "AMQP_URL",
+		DefaultURI: "amqp://rabbitmq:5672/",
+		// Celery and kombu aren't evidence: they work with Redis just as
+		// well (see celeryBroker)
+		Npm:       []string{"amqplib", "amqp-connection-manager"},
+		PyPI:      []string{"pika", "aio-pika"},
+		PyModules: []string{"pika", "aio_pika"},
+		GoModules: []string{"github.com/rabbitmq/amqp091-go", "github.com/streadway/amqp"},
+		Maven:     []string{"amqp-client", "spring-boot-starter-amqp"},
+		Schemes:   []string{"amqp", "amqps"},
+	},
+	{
+		Type:       "kafka",
+		Port:       "9092",
+		EnvVar:     "KAFKA_BROKERS",
+		DefaultURI: "kafka:9092",
+		ExtraEnv: map[string]string{
+			"KAFKA_BOOTSTRAP_SERVERS": "kafka:9092",
+		},
+		Npm:       []string{"kafkajs", "node-rdkafka"},
+		PyPI:      []string{"confluent-kafka", "kafka-python", "aiokafka"},
+		PyModules: []string{"confluent_kafka", "kafka", "aiokafka"},
+		GoModules: []string{"github.com/IBM/sarama", "github.com/Shopify/sarama", "github.com/segmentio/kafka-go", "github.com/confluentinc/confluent-kafka-go"},
+		Maven:     []string{"kafka-clients", "spring-kafka"},
+	},
+	{
+		Type:       "nats",
+		Port:       "4222",
+		EnvVar:     "NATS_URL",
+		DefaultURI: "nats://nats:4222",
+		Npm:        []string{"nats"},
+		PyPI:       []string{"nats-py"},
+		PyModules:  []string{"nats"},
+		GoModules:  []string{"github.com/nats-io/nats.go"},
+		Maven:      []string{"jnats"},
+		Schemes:    []string{"nats"},
+	},
+	{
+		Type:       "elasticsearch",
+		Port:       "9200",
+		EnvVar:     "ELASTICSEARCH_URL",
+		DefaultURI: "http://elasticsearch:9200",
+		Npm:        []string{"@elastic/elasticsearch", "elasticsearch"},
+		PyPI:       []string{"elasticsearch", "elasticsearch-dsl"},
+		PyModules:  []string{"elasticsearch", "elasticsearch_dsl"},
+		GoModules:  []string{"github.com/elastic/go-elasticsearch", "github.com/olivere/elastic"},
+		Maven:      []string{"elasticsearch-java", "elasticsearch-rest-high-level-client", "spring-boot-starter-data-elasticsearch"},
+	},
+	{
+		Type:       "opensearch",
+		Port:       "9200",
+		EnvVar:     "OPENSEARCH_URL",
+		DefaultURI: "http://opensearch:9200",
+		Npm:        []string{"@opensearch-project/opensearch"},
+		PyPI:       []string{"opensearch-py"},
+		PyModules:  []string{"opensearchpy"},
+		GoModules:  []string{"github.com/opensearch-project/opensearch-go"},
+		Maven:      []string{"opensearch-java", "opensearch-rest-high-level-client"},
+	},
+	{
+		Type:       "s3",
+		Port:       "9000",
+		EnvVar:     "S3_ENDPOINT",
+		DefaultURI: "http://minio:9000",
+		ExtraEnv: map[string]string{
+			"AWS_ENDPOINT_URL":        "http://minio:9000",
+			"AWS_REGION":              "us-east-1",
+			"AWS_S3_FORCE_PATH_STYLE": "true",
+		},
+		Npm:       []string{"@aws-sdk/client-s3", "aws-sdk", "minio"},
+		PyPI:      []string{"boto3", "minio", "s3fs"},
+		PyModules: []string{"boto3", "minio", "s3fs"},
+		GoModules: []string{"github.com/minio/minio-go", "github.com/aws/aws-sdk-go-v2/service/s3", "github.com/aws/aws-sdk-go"},
+		Maven:     []string{"aws-java-sdk-s3", "s3", "minio"},
+		Schemes:   []string{"s3"},
+	},
+	{
+		Type:       "smtp",
+		Port:       "1025",
+		EnvVar:     "SMTP_URL",
+		DefaultURI: "smtp://mailpit:1025",
+		ExtraEnv: map[string]string{
+			"SMTP_HOST":  "mailpit",
+			"SMTP_PORT":  "1025",
+			"EMAIL_HOST": "mailpit",
+			"EMAIL_PORT": "1025",
+		},
+		Npm:       []string{"nodemailer"},
+		PyPI:      []string{"flask-mail", "aiosmtplib"},
+		PyModules: []string{"smtplib", "flask_mail", "aiosmtplib", "django.core.mail"},
+		GoModules: []string{"net/smtp", "gopkg.in/gomail.v2", "github.com/wneessen/go-mail"},
+		Maven:     []string{"spring-boot-starter-mail", "javax.mail", "jakarta.mail"},
+		Schemes:   []string{"smtp", "smtps"},
+	},
+}
+
+// Directories never worth scanning for imports
+var skipDirs = map[string]bool{
+	".git": true, "node_modules": true, "vendor": true, ".venv": true, "venv": true,
+	"__pycache__": true, "dist": true, "build": true, ".next": true, "target": true,
+}
+
+// DetectDependencies parses manifests, source imports and .env URLs for
+// every backing service in the catalog and returns them with their
+// evidence and a combined confidence score.
+func DetectDependencies(path string) []Dependency {
+	found := make(map[string]*Dependency)
+
+	// 1. Declared dependencies
+	manifests := ReadManifests(path)
+	for _, spec := range dependencyCatalog {
+		for _, name := range spec.Npm {
+			if manifests.Npm[name] {
+				addEvidence(found, spec, Evidence{File: "package.json", Match: name, Source: "manifest", Confidence: confidenceManifest})
+			} else if manifests.NpmDev[name] {
+				addEvidence(found, spec, Evidence{File: "package.json", Match: name, Source: "dev-manifest", Confidence: confidenceDevManifest})
+			}
+		}
+		for _, name := range spec.PyPI {
+			if manifests.PyPI[NormalizePyPI(name)] {
+				addEvidence(found, spec, Evidence{File: pythonManifestName(path), Match: name, Source: "manifest", Confidence: confidenceManifest})
+			}
+		}
+		for _, mod := range manifests.GoModules {
+			if name := matchGoModule(spec.GoModules, mod); name != "" {
+				addEvidence(found, spec, Evidence{File: "go.mod", Match: mod, Source: "manifest", Confidence: confidenceManifest})
+			}
+		}
+		for _, name := range spec.Maven {
+			if manifests.Maven[name] {
+				addEvidence(found, spec, Evidence{File: "pom.xml", Match: name, Source: "manifest", Confidence: confidenceManifest})
+			}
+		}
+	}
+
+	// 2. Import statements in source files
+	filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if skipDirs[info.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, _ := filepath.Rel(path, p)
+		rel = filepath.ToSlash(rel)
+
+		switch filepath.Ext(p) {
+		case ".js", ".jsx", ".ts", ".tsx", ".mjs", ".cjs":
+			content, _ := os.ReadFile(p)
+			for _, pkg := range jsImports(string(content)) {
+				matchImport(found, rel, pkg, func(s dependencySpec) []string { return s.Npm }, equalName)
+			}
+		case ".py":
+			content, _ := os.ReadFile(p)
+			for _, mod := range pyImports(string(content)) {
+				matchImport(found, rel, mod, func(s dependencySpec) []string { return s.PyModules }, dottedPrefix)
+			}
+		case ".go":
+			content, _ := os.ReadFile(p)
+			for _, imp := range goImports(string(content)) {
+				matchImport(found, rel, imp, func(s dependencySpec) []string { return s.GoModules }, func(want, got string) bool {
+					return matchGoModule([]string{want}, got) != ""
+				})
+			}
+		}
+		return nil
+	})
+
+	// 3. Connection URLs in .env
+	detectEnvURLs(path, found)
+
+	var deps []Dependency
+	for _, spec := range dependencyCatalog {
+		if d, ok := found[spec.Type]; ok {
+			deps = append(deps, *d)
+		}
+	}
+	return deps
+}
+
+// FilterByConfidence splits deps into those at or above min and the rest
+func FilterByConfidence(deps []Dependency, min float64) (kept, dropped []Dependency) {
+	for _, d := range deps {
+		if d.Confidence >= min {
+			kept = append(kept, d)
+		} else {
+			dropped = append(dropped, d)
+		}
+	}
+	return kept, dropped
+}
+
+func addEvidence(found map[string]*Dependency, spec dependencySpec, ev Evidence) {
+	d, ok := found[spec.Type]
+	if !ok {
+		d = &Dependency{
+			Type:       spec.Type,
+			Port:       spec.Port,
+			EnvVar:     spec.EnvVar,
+			DefaultURI: spec.DefaultURI,
+			ExtraEnv:   spec.ExtraEnv,
+		}
+		found[spec.Type] = d
+	}
+
+	d.Evidence = append(d.Evidence, ev)
+
+	// Independent signals: 1 - Π(1 - c)
+	d.Confidence = 1 - (1-d.Confidence)*(1-ev.Confidence)
+}
+
+func matchImport(found map[string]*Dependency, file, imported string, names func(dependencySpec) []string, match func(want, got string) bool) {
+	for _, spec := range dependencyCatalog {
+		for _, want := range names(spec) {
+			if match(want, imported) {
+				addEvidence(found, spec, Evidence{File: file, Match: imported, Source: "import", Confidence: confidenceImport})
+				break
+			}
+		}
+	}
+}
+
+func equalName(want, got string) bool {
+	return want == got
+}
+
+// dottedPrefix matches Python modules: "mysql.connector" matches imports of
+// "mysql.connector" and "mysql.connector.pooling", not "mysql_utils"
+func dottedPrefix(want, got string) bool {
+	return got == want || strings.HasPrefix(got, want+".")
+}
+
+// matchGoModule returns the catalog module that path belongs to, if any.
+// Major version suffixes (/v5) and sub-packages are accepted.
+func matchGoModule(modules []string, path string) string {
+	lower := strings.ToLower(path)
+	for _, m := range modules {
+		lm := strings.ToLower(m)
+		if lower == lm || strings.HasPrefix(lower, lm+"/") {
+			return m
+		}
+	}
+	return ""
+}
+
+func pythonManifestName(path string) string {
+	if fileExists(filepath.Join(path, "requirements.txt")) {
+		return "requirements.txt"
+	}
+	return "pyproject.toml"
+}
+
+// ---------------------------------------------------
+// IMPORT PARSING
+// ---------------------------------------------------
+
+var (
+	jsImportPattern = regexp.MustCompile(`(?m)(?:\brequire\(\s*|\bimport\s*\(\s*|\bfrom\s+|^\s*import\s+)['"]([^'"]+)['"]`)
+	pyImportPattern = regexp.MustCompile(`(?m)^\s*(?:from\s+([\w.]+)\s+import\b|import\s+([\w.]+(?:\s+as\s+\w+)?(?:\s*,\s*[\w.]+(?:\s+as\s+\w+)?)*))`)
+	goImportSingle  = regexp.MustCompile(`(?m)^\s*import\s+(?:[\w.]+\s+)?"([^"]+)"`)
+	goImportBlock   = regexp.MustCompile(`(?s)\bimport\s*\((.*?)\)`)
+	goImportLine    = regexp.MustCompile(`"([^"]+)"`)
+)
+
+// jsImports returns the npm package names imported by a JS/TS file
+func jsImports(content string) []string {
+	var pkgs []string
+	for _, m := range jsImportPattern.FindAllStringSubmatch(content, -1) {
+		spec := strings.TrimPrefix(m[1], "node:")
+		if strings.HasPrefix(spec, ".") || strings.HasPrefix(spec, "/") {
+			continue
+		}
+
+		parts := strings.Split(spec, "/")
+		name := parts[0]
+		if strings.HasPrefix(name, "@") && len(parts) > 1 {
+			name += "/" + parts[1]
+		}
+		pkgs = append(pkgs, name)
+	}
+	return pkgs
+}
+
+// pyImports returns the dotted module paths imported by a Python file
+func pyImports(content string) []string {
+	var mods []string
+	for _, m := range pyImportPattern.FindAllStringSubmatch(content, -1) {
+		if m[1] != "" {
+			mods = append(mods, m[1])
+			continue
+		}
+		for _, part := range strings.Split(m[2], ",") {
+			fields := strings.Fields(part)
+			if len(fields) > 0 {
+				mods = append(mods, fields[0])
+			}
+		}
+	}
+	return mods
+}
+
+// goImports returns the import paths of a Go file
+func goImports(content string) []string {
+	var paths []string
+	for _, m := range goImportSingle.FindAllStringSubmatch(content, -1) {
+		paths = append(paths, m[1])
+	}
+	for _, block := range goImportBlock.FindAllStringSubmatch(content, -1) {
+		for _, line := range strings.Split(block[1], "\n") {
+			line = strings.TrimSpace(line)
+			if strings.HasPrefix(line, "//") {
+				continue
+			}
+			if m := goImportLine.FindStringSubmatch(line); m != nil {
+				paths = append(paths, m[1])
+			}
+		}
+	}
+	return paths
+}
+
+// ---------------------------------------------------
+// .env CONNECTION URLS
+// ---------------------------------------------------
+
+func detectEnvURLs(path string, found map[string]*Dependency) {
+	f, err := os.Open(filepath.Join(path, ".env"))
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+
+		scheme, _, ok := strings.Cut(value, "://")
+		if !ok {
+			continue
+		}
+		scheme = strings.ToLower(scheme)
+
+		for _, spec := range dependencyCatalog {
+			for _, s := range spec.Schemes {
+				if s == scheme {
+					addEvidence(found, spec, Evidence{File: ".env", Match: strings.TrimSpace(key), Source: "env", Confidence: confidenceEnvURL})
+				}
+			}
+		}
+	}
+}
+
+// HasDependency reports whether deps contains a service of the given type
+func HasDependency(deps []Dependency, depType string) bool {
+	for _, d := range deps {
+		if d.Type == depType {
+			return true
+		}
+	}
+	return false
+}
+
+// isDependencyType reports whether name is a catalog service type
+func isDependencyType(name string) bool {
+	for _, spec := range dependencyCatalog {
+		if spec.Type == name {
+			return true
+		}
+	}
+	return false
+}
+
+// RequireDependency adds the catalog service depType to deps when a process
+// needs it but detection missed it (e.g. "celery[redis]" extras). The
+// result stays in catalog order.
+func RequireDependency(deps []Dependency, depType string, ev Evidence) []Dependency {
+	if depType == "" || HasDependency(deps, depType) {
+		return deps
+	}
+
+	found := make(map[string]*Dependency)
+	for i := range deps {
+		found[deps[i].Type] = &deps[i]
+	}
+	for _, spec := range dependencyCatalog {
+		if spec.Type == depType {
+			addEvidence(found, spec, ev)
+		}
+	}
+
+	var out []Dependency
+	for _, spec := range dependencyCatalog {
+		if d, ok := found[spec.Type]; ok {
+			out = append(out, *d)
+		}
+	}
+	return out
+}
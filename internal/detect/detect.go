@@ -42,6 +42,12 @@ func DetectStack(repoPath string) (*TechStack, error) {
 			return jsType, nil
 		}
 
+		// ==== RUST ====
+		if name == "Cargo.toml" {
+			tech.Primary = "rust"
+			return tech, nil
+		}
+
 		// ==== JAVA ====
 		if name == "pom.xml" {
 			tech.Primary = "java-maven"
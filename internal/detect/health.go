@@ -0,0 +1,51 @@
+package detect
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// healthPaths in order of preference when a repo defines several
+var healthPaths = []string{"/healthz", "/health", "/actuator/health", "/readyz", "/api/health", "/api/healthz"}
+
+var healthRoutePattern = regexp.MustCompile("[\"'`](/(?:api/)?(?:healthz?|readyz|actuator/health))[\"'`]")
+
+// DetectHealthEndpoint looks for an HTTP health route declared in the
+// app's source and returns its path, or "" when there is none.
+func DetectHealthEndpoint(path string) string {
+	found := map[string]bool{}
+
+	// Spring Boot exposes /actuator/health once the starter is present
+	if ReadManifests(path).Maven["spring-boot-starter-actuator"] {
+		found["/actuator/health"] = true
+	}
+
+	filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if skipDirs[info.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		switch filepath.Ext(p) {
+		case ".js", ".jsx", ".ts", ".mjs", ".cjs", ".py", ".go", ".java", ".kt":
+			content, _ := os.ReadFile(p)
+			for _, m := range healthRoutePattern.FindAllStringSubmatch(string(content), -1) {
+				found[m[1]] = true
+			}
+		}
+		return nil
+	})
+
+	for _, h := range healthPaths {
+		if found[h] {
+			return h
+		}
+	}
+	return ""
+}
@@ -0,0 +1,323 @@
+package detect
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Manifests holds the dependency names declared in a repo's package
+// manifests, normalised per ecosystem.
+type Manifests struct {
+	Npm       map[string]bool
+	NpmDev    map[string]bool
+	PyPI      map[string]bool
+	GoModules []string // direct requirements only
+	Maven     map[string]bool
+
+	// Packages are the npm, PyPI and Go dependencies with their versions.
+	// npm takes every installed package from package-lock.json when there
+	// is one.
+	Packages []Package
+}
+
+// Package is a dependency and the exact version its manifest or lock
+// file pins, "" for ranges and unpinned requirements
+type Package struct {
+	Name      string
+	Version   string
+	Ecosystem string // npm, pypi or golang
+	Dev       bool   // development dependency (still installed by npm install)
+	Source    string // file it was read from
+}
+
+// ReadManifests parses package.json (and package-lock.json),
+// requirements.txt, pyproject.toml, go.mod and pom.xml in the repo root.
+// Missing files are skipped.
+func ReadManifests(path string) Manifests {
+	m := Manifests{
+		Npm:    map[string]bool{},
+		NpmDev: map[string]bool{},
+		PyPI:   map[string]bool{},
+		Maven:  map[string]bool{},
+	}
+
+	readPackageJSON(filepath.Join(path, "package.json"), &m)
+	readPackageLock(filepath.Join(path, "package-lock.json"), &m)
+	readRequirements(filepath.Join(path, "requirements.txt"), &m)
+	readPyproject(filepath.Join(path, "pyproject.toml"), &m)
+	readGoMod(filepath.Join(path, "go.mod"), &m)
+	readPom(filepath.Join(path, "pom.xml"), &m)
+
+	sort.Slice(m.Packages, func(i, j int) bool {
+		a, b := m.Packages[i], m.Packages[j]
+		if a.Ecosystem != b.Ecosystem {
+			return a.Ecosystem < b.Ecosystem
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Version < b.Version
+	})
+	return m
+}
+
+// ---------------------------------------------------
+// NODE
+// ---------------------------------------------------
+
+func readPackageJSON(path string, m *Manifests) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+
+	var pkg struct {
+		Dependencies         map[string]string `json:"dependencies"`
+		DevDependencies      map[string]string `json:"devDependencies"`
+		PeerDependencies     map[string]string `json:"peerDependencies"`
+		OptionalDependencies map[string]string `json:"optionalDependencies"`
+	}
+	if json.Unmarshal(data, &pkg) != nil {
+		return
+	}
+
+	for _, deps := range []map[string]string{pkg.Dependencies, pkg.PeerDependencies, pkg.OptionalDependencies} {
+		for name, spec := range deps {
+			m.Npm[name] = true
+			m.Packages = append(m.Packages, Package{Name: name, Version: exactVersion(spec), Ecosystem: "npm", Source: "package.json"})
+		}
+	}
+	for name, spec := range pkg.DevDependencies {
+		m.NpmDev[name] = true
+		m.Packages = append(m.Packages, Package{Name: name, Version: exactVersion(spec), Ecosystem: "npm", Dev: true, Source: "package.json"})
+	}
+}
+
+// readPackageLock replaces the package.json versions with every package
+// a lockfile v2/v3 installs. It runs right after readPackageJSON, so npm
+// packages are all m.Packages holds.
+func readPackageLock(path string, m *Manifests) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+
+	var lock struct {
+		Packages map[string]struct {
+			Version string `json:"version"`
+			Dev     bool   `json:"dev"`
+		} `json:"packages"`
+	}
+	if json.Unmarshal(data, &lock) != nil || len(lock.Packages) == 0 {
+		return
+	}
+
+	var pkgs []Package
+	for key, p := range lock.Packages {
+		i := strings.LastIndex(key, "node_modules/")
+		if i < 0 {
+			continue // the root project
+		}
+		pkgs = append(pkgs, Package{
+			Name: key[i+len("node_modules/"):], Version: p.Version,
+			Ecosystem: "npm", Dev: p.Dev, Source: "package-lock.json",
+		})
+	}
+
+	m.Packages = pkgs
+}
+
+var exactSemver = regexp.MustCompile(`^=?v?(\d+\.\d+\.\d+[0-9A-Za-z.+-]*)$`)
+
+// exactVersion returns the version of an exact npm or Poetry spec, ""
+// for ranges
+func exactVersion(spec string) string {
+	if m := exactSemver.FindStringSubmatch(strings.TrimSpace(spec)); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// ---------------------------------------------------
+// PYTHON
+// ---------------------------------------------------
+
+// Requirement name, extras and an exact == or === pin
+var pyRequirement = regexp.MustCompile(`^\s*([A-Za-z0-9][A-Za-z0-9._-]*)(?:\[[^\]]*\])?\s*(?:===?\s*([^\s;,#]+))?`)
+
+// NormalizePyPI applies PEP 503 name normalisation
+func NormalizePyPI(name string) string {
+	name = strings.ToLower(name)
+	name = strings.NewReplacer("_", "-", ".", "-").Replace(name)
+	return name
+}
+
+// addPyPI records a Python dependency once, from the first file naming it
+func addPyPI(name, version, source string, m *Manifests) {
+	name = NormalizePyPI(name)
+	if m.PyPI[name] {
+		return
+	}
+	m.PyPI[name] = true
+	m.Packages = append(m.Packages, Package{Name: name, Version: version, Ecosystem: "pypi", Source: source})
+}
+
+func addRequirement(spec, source string, m *Manifests) {
+	if match := pyRequirement.FindStringSubmatch(spec); match != nil {
+		addPyPI(match[1], match[2], source, m)
+	}
+}
+
+func readRequirements(path string, m *Manifests) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		// skip options such as -r, -e, --index-url
+		if line == "" || strings.HasPrefix(line, "-") {
+			continue
+		}
+		addRequirement(line, "requirements.txt", m)
+	}
+}
+
+var quotedString = regexp.MustCompile(`["']([^"']+)["']`)
+
+// readPyproject understands PEP 621 dependency arrays and Poetry tables.
+// It is a line-oriented reader, not a full TOML parser.
+func readPyproject(path string, m *Manifests) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	table := ""
+	inArray := false
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if inArray {
+			for _, q := range quotedString.FindAllStringSubmatch(line, -1) {
+				addRequirement(q[1], "pyproject.toml", m)
+			}
+			if strings.Contains(quotedString.ReplaceAllString(line, ""), "]") {
+				inArray = false
+			}
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			table = strings.Trim(line, "[] ")
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		switch {
+		case strings.HasPrefix(table, "tool.poetry") && strings.HasSuffix(table, "dependencies"):
+			if key != "python" {
+				addPyPI(strings.Trim(key, `"'`), exactVersion(strings.Trim(value, `"'`)), "pyproject.toml", m)
+			}
+		case (table == "project" && key == "dependencies") ||
+			strings.HasPrefix(table, "project.optional-dependencies"):
+			if strings.HasPrefix(value, "[") {
+				for _, q := range quotedString.FindAllStringSubmatch(value, -1) {
+					addRequirement(q[1], "pyproject.toml", m)
+				}
+				inArray = !strings.Contains(quotedString.ReplaceAllString(value, ""), "]")
+			}
+		}
+	}
+}
+
+// ---------------------------------------------------
+// GO
+// ---------------------------------------------------
+
+// readGoMod lists every required module as a package, but only direct
+// requirements in GoModules: "// indirect" ones come in through other
+// modules and say nothing about the services the app talks to.
+func readGoMod(path string, m *Manifests) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	var pkgs []Package
+	add := func(fields []string, indirect bool) {
+		if len(fields) >= 2 {
+			if !indirect {
+				m.GoModules = append(m.GoModules, fields[0])
+			}
+			pkgs = append(pkgs, Package{Name: fields[0], Version: fields[1], Ecosystem: "golang", Source: "go.mod"})
+		}
+	}
+	inBlock := false
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		indirect := false
+		if i := strings.Index(line, "//"); i >= 0 {
+			comment := strings.TrimSpace(line[i+2:])
+			indirect = comment == "indirect" || strings.HasPrefix(comment, "indirect;")
+			line = strings.TrimSpace(line[:i])
+		}
+
+		switch {
+		case line == "require (":
+			inBlock = true
+		case inBlock && line == ")":
+			inBlock = false
+		case inBlock && line != "":
+			add(strings.Fields(line), indirect)
+		case strings.HasPrefix(line, "require "):
+			add(strings.Fields(line)[1:], indirect)
+		}
+	}
+
+	m.Packages = append(m.Packages, pkgs...)
+}
+
+// ---------------------------------------------------
+// JAVA
+// ---------------------------------------------------
+
+var pomDependency = regexp.MustCompile(`(?s)<dependency>.*?<artifactId>\s*([^<\s]+)\s*</artifactId>.*?</dependency>`)
+
+func readPom(path string, m *Manifests) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+
+	for _, match := range pomDependency.FindAllStringSubmatch(string(data), -1) {
+		m.Maven[strings.ToLower(match[1])] = true
+	}
+}
@@ -0,0 +1,194 @@
+package detect
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// MigrationInfo describes the schema migration and seed tooling of a repo
+type MigrationInfo struct {
+	Tool     string // prisma, knex, sequelize, typeorm, alembic, django, rails, golang-migrate, goose, flyway, liquibase
+	Evidence string
+
+	// Dir is the migrations directory for tools run from their own image
+	Dir string
+	// Config is a tool-specific entry file (TypeORM data source, Liquibase changelog)
+	Config string
+	// Script is an npm script that runs migrations, preferred when present
+	Script string
+
+	SeedTool  string   // knex, sequelize, prisma, django, rails
+	SeedFiles []string // SQL/JSON fixtures, relative to the repo root
+}
+
+var npmMigrateScripts = []string{"migrate", "db:migrate", "migration:run", "migrations:run", "migrate:deploy"}
+
+var migrationDirs = []string{"migrations", "db/migrations", "database/migrations", "sql/migrations", "internal/db/migrations", "migrate"}
+
+var seedDirs = []string{"seeds", "seed", "db/seeds", "database/seeds", "fixtures", "db/fixtures", "sql/seeds"}
+
+// DetectMigrations finds the migration tool and seed data of a repo
+func DetectMigrations(path string) MigrationInfo {
+	info := MigrationInfo{}
+
+	m := ReadManifests(path)
+	npm := func(name string) bool { return m.Npm[name] || m.NpmDev[name] }
+	goMod := func(mod string) bool {
+		for _, req := range m.GoModules {
+			if matchGoModule([]string{mod}, req) != "" {
+				return true
+			}
+		}
+		return false
+	}
+	has := func(rel string) bool { return fileExists(filepath.Join(path, rel)) }
+
+	scripts := packageScripts(path)
+
+	switch {
+	case (npm("prisma") || npm("@prisma/client")) && has("prisma/schema.prisma"):
+		info.Tool, info.Evidence, info.Dir = "prisma", "prisma/schema.prisma", "prisma/migrations"
+	case npm("knex") && (has("knexfile.js") || has("knexfile.ts")):
+		info.Tool, info.Evidence = "knex", "knexfile"
+	case npm("sequelize-cli") || has(".sequelizerc"):
+		info.Tool, info.Evidence = "sequelize", "sequelize-cli"
+	case npm("typeorm"):
+		if cfg := findFirst(path, []string{"src/data-source.ts", "src/data-source.js", "data-source.ts", "data-source.js", "src/db/data-source.ts", "ormconfig.ts", "ormconfig.js"}); cfg != "" {
+			info.Tool, info.Evidence, info.Config = "typeorm", cfg, cfg
+		}
+	case m.PyPI["alembic"] && has("alembic.ini"):
+		info.Tool, info.Evidence = "alembic", "alembic.ini"
+	case has("manage.py"):
+		info.Tool, info.Evidence = "django", "manage.py"
+	case has("Gemfile") && has("db/migrate") && fileContains(filepath.Join(path, "Gemfile"), "rails"):
+		info.Tool, info.Evidence, info.Dir = "rails", "db/migrate", "db/migrate"
+	case goMod("github.com/golang-migrate/migrate"):
+		info.Tool, info.Evidence, info.Dir = "golang-migrate", "go.mod", findFirst(path, migrationDirs)
+	case goMod("github.com/pressly/goose"):
+		info.Tool, info.Evidence, info.Dir = "goose", "go.mod", findFirst(path, migrationDirs)
+	case m.Maven["flyway-core"] || has("flyway.conf") || has("flyway.toml") || has("src/main/resources/db/migration"):
+		info.Tool, info.Evidence = "flyway", "flyway"
+		info.Dir = findFirst(path, []string{"src/main/resources/db/migration", "db/migration", "sql"})
+	case m.Maven["liquibase-core"] || has("liquibase.properties"):
+		info.Tool, info.Evidence = "liquibase", "liquibase"
+		info.Config = findFirst(path, []string{
+			"src/main/resources/db/changelog/db.changelog-master.yaml",
+			"src/main/resources/db/changelog/db.changelog-master.xml",
+			"db/changelog/db.changelog-master.yaml",
+			"db/changelog/db.changelog-master.xml",
+			"changelog.xml",
+		})
+	}
+
+	// Tools run from their own image need the migrations on disk
+	if (info.Tool == "golang-migrate" || info.Tool == "goose" || info.Tool == "flyway") && info.Dir == "" {
+		info.Tool = ""
+	}
+	if info.Tool == "liquibase" && info.Config == "" {
+		info.Tool = ""
+	}
+
+	// Prefer the project's own npm script for node tools
+	switch info.Tool {
+	case "prisma", "knex", "sequelize", "typeorm":
+		for _, s := range npmMigrateScripts {
+			if _, ok := scripts[s]; ok {
+				info.Script = s
+				break
+			}
+		}
+	}
+
+	detectSeeds(path, &info, npm)
+
+	return info
+}
+
+func detectSeeds(path string, info *MigrationInfo, npm func(string) bool) {
+	has := func(rel string) bool { return fileExists(filepath.Join(path, rel)) }
+
+	switch {
+	case info.Tool == "knex" && (has("seeds") || has("db/seeds")):
+		info.SeedTool = "knex"
+		return
+	case info.Tool == "sequelize" && (has("seeders") || has("db/seeders")):
+		info.SeedTool = "sequelize"
+		return
+	case info.Tool == "prisma" && prismaSeedConfigured(path):
+		info.SeedTool = "prisma"
+		return
+	case info.Tool == "rails" && has("db/seeds.rb"):
+		info.SeedTool = "rails"
+		return
+	}
+
+	for _, dir := range seedDirs {
+		entries, err := os.ReadDir(filepath.Join(path, dir))
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			ext := filepath.Ext(e.Name())
+			if !e.IsDir() && (ext == ".sql" || ext == ".json") {
+				info.SeedFiles = append(info.SeedFiles, filepath.ToSlash(filepath.Join(dir, e.Name())))
+			}
+		}
+		if len(info.SeedFiles) > 0 {
+			break
+		}
+	}
+
+	// Django loads JSON fixtures through its own loaddata
+	if info.Tool == "django" && len(info.SeedFiles) > 0 {
+		info.SeedTool = "django"
+	}
+}
+
+// packageScripts returns the "scripts" section of package.json
+func packageScripts(path string) map[string]string {
+	data, err := os.ReadFile(filepath.Join(path, "package.json"))
+	if err != nil {
+		return nil
+	}
+
+	var pkg struct {
+		Scripts map[string]string `json:"scripts"`
+	}
+	json.Unmarshal(data, &pkg)
+	return pkg.Scripts
+}
+
+func prismaSeedConfigured(path string) bool {
+	data, err := os.ReadFile(filepath.Join(path, "package.json"))
+	if err != nil {
+		return false
+	}
+
+	var pkg struct {
+		Prisma struct {
+			Seed string `json:"seed"`
+		} `json:"prisma"`
+	}
+	json.Unmarshal(data, &pkg)
+	return pkg.Prisma.Seed != ""
+}
+
+// findFirst returns the first of the candidate paths that exists
+func findFirst(path string, candidates []string) string {
+	for _, c := range candidates {
+		if fileExists(filepath.Join(path, c)) {
+			return c
+		}
+	}
+	return ""
+}
+
+func fileContains(path, substr string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	return strings.Contains(string(data), substr)
+}
@@ -0,0 +1,150 @@
+package detect
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// PersistenceInfo describes state the app keeps on its own filesystem
+type PersistenceInfo struct {
+	// SQLiteDriver is the SQLite library in use, if any
+	SQLiteDriver string
+	// CGO is set when the Go SQLite driver needs cgo
+	CGO bool
+
+	// Dirs are directories, relative to the app root, that must survive
+	// container restarts (SQLite files, uploads, media)
+	Dirs []string
+
+	// RootFiles are database files sitting directly in the app root. A
+	// volume can't be mounted there without hiding the code, so the
+	// generator links them into a data directory that gets one.
+	RootFiles []string
+}
+
+type sqliteDriver struct {
+	Name string
+	CGO  bool
+}
+
+var (
+	sqliteNpm  = []string{"sqlite3", "better-sqlite3", "sqlite"}
+	sqlitePyPI = []string{"aiosqlite"}
+	sqliteGo   = map[string]sqliteDriver{
+		"github.com/mattn/go-sqlite3": {"mattn/go-sqlite3", true},
+		"gorm.io/driver/sqlite":       {"gorm sqlite (mattn/go-sqlite3)", true},
+		"modernc.org/sqlite":          {"modernc.org/sqlite", false},
+		"github.com/glebarez/sqlite":  {"glebarez/sqlite", false},
+	}
+
+	sqliteFilePattern = regexp.MustCompile(`["'](?:sqlite(?:3)?:/{2,3})?(?:\./)?([\w][\w./-]*\.(?:db|sqlite3?))["']`)
+	dataDirPattern    = regexp.MustCompile(`(?i)\b(?:dest|upload_folder|upload_dir|uploads_dir|media_root|storage_path|data_dir)\b\s*[:=]\s*(?:[\w.]+\s*/\s*)?["']\.?/?([\w][\w/-]*)/?["']`)
+	pyImportSQLite    = regexp.MustCompile(`(?m)^\s*import\s+sqlite3\b`)
+	djangoSQLite      = "django.db.backends.sqlite3"
+)
+
+// DetectPersistence finds SQLite databases and upload/data directories
+func DetectPersistence(repoPath string) PersistenceInfo {
+	info := PersistenceInfo{}
+
+	m := ReadManifests(repoPath)
+	for _, name := range sqliteNpm {
+		if m.Npm[name] {
+			info.SQLiteDriver = name
+		}
+	}
+	for _, name := range sqlitePyPI {
+		if m.PyPI[name] {
+			info.SQLiteDriver = name
+		}
+	}
+	for _, mod := range m.GoModules {
+		for prefix, d := range sqliteGo {
+			if matchGoModule([]string{prefix}, mod) != "" {
+				info.SQLiteDriver = d.Name
+				info.CGO = info.CGO || d.CGO
+			}
+		}
+	}
+
+	dirs := map[string]bool{}
+	sqliteDirs := map[string]bool{}
+	rootFiles := map[string]bool{}
+
+	addPath := func(rel string, isFile bool) {
+		rel = path.Clean(strings.TrimPrefix(rel, "/"))
+		if rel == "." || strings.HasPrefix(rel, "..") {
+			return
+		}
+		dir := rel
+		if isFile {
+			dir = path.Dir(rel)
+		}
+		if dir == "." {
+			rootFiles[rel] = true
+			return
+		}
+		// Persist the top-level directory so nested paths share one volume
+		top := strings.Split(dir, "/")[0]
+		if isFile {
+			sqliteDirs[top] = true
+		} else {
+			dirs[top] = true
+		}
+	}
+
+	filepath.Walk(repoPath, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if fi.IsDir() {
+			if skipDirs[fi.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		switch filepath.Ext(p) {
+		case ".js", ".ts", ".mjs", ".cjs", ".py", ".go":
+		default:
+			return nil
+		}
+
+		data, _ := os.ReadFile(p)
+		content := string(data)
+
+		if strings.Contains(content, djangoSQLite) {
+			info.SQLiteDriver = "django sqlite3"
+			rootFiles["db.sqlite3"] = true
+		}
+		if filepath.Ext(p) == ".py" && info.SQLiteDriver == "" && pyImportSQLite.MatchString(content) {
+			info.SQLiteDriver = "sqlite3"
+		}
+
+		for _, match := range sqliteFilePattern.FindAllStringSubmatch(content, -1) {
+			// The engine name looks like a file name too
+			if match[1] != djangoSQLite {
+				addPath(match[1], true)
+			}
+		}
+		for _, match := range dataDirPattern.FindAllStringSubmatch(content, -1) {
+			addPath(match[1], false)
+		}
+		return nil
+	})
+
+	// SQLite file names found without any SQLite library are just strings
+	if info.SQLiteDriver != "" {
+		for d := range sqliteDirs {
+			dirs[d] = true
+		}
+		info.RootFiles = SortedKeys(rootFiles)
+	}
+
+	info.Dirs = SortedKeys(dirs)
+
+	return info
+}
@@ -0,0 +1,379 @@
+package detect
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ProcessInfo is an extra long-running process that shares the app image
+type ProcessInfo struct {
+	Name     string   // compose service name: worker, beat, scheduler, ...
+	Kind     string   // celery-worker, celery-beat, rq-worker, rq-scheduler, bullmq, sidekiq, procfile, npm-script
+	Command  []string // exec form; "sh -c" when env expansion is needed
+	Broker   string   // dependency type the process consumes from, if any
+	Evidence string
+}
+
+var (
+	celeryAppPattern  = regexp.MustCompile(`\bCelery\s*\(`)
+	celeryBeatPattern = regexp.MustCompile(`beat_schedule|CELERY_BEAT_SCHEDULE|django_celery_beat|periodic_task|add_periodic_task`)
+	bullWorkerPattern = regexp.MustCompile(`new\s+Worker\s*\(|\.process\s*\(`)
+	procfileLine      = regexp.MustCompile(`^([A-Za-z0-9_-]+)\s*:\s*(.+)$`)
+
+	// broker_url = "redis://...", CELERY_BROKER_URL=amqp://...,
+	// Celery(broker=os.getenv("BROKER_URL", "redis://..."))
+	celeryBrokerURL = regexp.MustCompile(`(?i)\b(?:celery_)?broker(?:_url)?\b["']?\s*[:=]\s*(?:os\.(?:environ\.get|getenv)\(\s*["']\w+["']\s*,\s*)?["']?([a-z0-9+]+)://`)
+	celeryExtras    = regexp.MustCompile(`(?i)\bcelery\[([^\]]*)\]`)
+)
+
+// Compose service keys the generator uses for the app and its backing
+// services; processes can't take them
+var reservedServiceNames = map[string]bool{
+	"app": true, "migrate": true, "seed": true, "minio": true, "minio-init": true, "mailpit": true,
+}
+
+// Celery broker URL schemes and the dependency serving them
+var celeryBrokerSchemes = map[string]string{
+	"amqp": "rabbitmq", "amqps": "rabbitmq", "pyamqp": "rabbitmq", "librabbitmq": "rabbitmq",
+	"redis": "redis", "rediss": "redis", "sentinel": "redis",
+}
+
+// npm scripts that start background processes, mapped to service names
+var npmProcessScripts = []string{"worker", "queue", "jobs", "scheduler", "cron"}
+
+// DetectProcesses finds worker and scheduler processes next to the main
+// app process. A Procfile, when present, is authoritative.
+func DetectProcesses(path string) []ProcessInfo {
+	if procs := procfileProcesses(path); len(procs) > 0 {
+		for i := range procs {
+			if procs[i].Broker == "celery" {
+				procs[i].Broker = celeryBroker(path)
+			}
+		}
+		return dedupeProcessNames(procs)
+	}
+
+	var procs []ProcessInfo
+	m := ReadManifests(path)
+	npm := func(name string) bool { return m.Npm[name] || m.NpmDev[name] }
+
+	// ==== PYTHON ====
+	if m.PyPI["celery"] {
+		if mod, file := findCeleryApp(path); mod != "" {
+			broker := celeryBroker(path)
+
+			procs = append(procs, ProcessInfo{
+				Name:     "worker",
+				Kind:     "celery-worker",
+				Command:  []string{"celery", "-A", mod, "worker", "--loglevel=INFO"},
+				Broker:   broker,
+				Evidence: file,
+			})
+
+			if repoMatches(path, celeryBeatPattern, ".py") {
+				// beat keeps its schedule in the working directory by default,
+				// which a read-only, non-root container can't write
+				procs = append(procs, ProcessInfo{
+					Name:     "beat",
+					Kind:     "celery-beat",
+					Command:  []string{"celery", "-A", mod, "beat", "--loglevel=INFO", "--schedule", "/tmp/celerybeat-schedule"},
+					Broker:   broker,
+					Evidence: file,
+				})
+			}
+		}
+	}
+
+	if m.PyPI["rq"] {
+		procs = append(procs, ProcessInfo{
+			Name:     "worker",
+			Kind:     "rq-worker",
+			Command:  []string{"sh", "-c", `rq worker --url "$REDIS_URL"`},
+			Broker:   "redis",
+			Evidence: "rq",
+		})
+	}
+	if m.PyPI["rq-scheduler"] {
+		procs = append(procs, ProcessInfo{
+			Name:     "scheduler",
+			Kind:     "rq-scheduler",
+			Command:  []string{"sh", "-c", `rqscheduler --url "$REDIS_URL"`},
+			Broker:   "redis",
+			Evidence: "rq-scheduler",
+		})
+	}
+
+	// ==== RUBY ====
+	if fileContains(filepath.Join(path, "Gemfile"), "sidekiq") {
+		procs = append(procs, ProcessInfo{
+			Name:     "worker",
+			Kind:     "sidekiq",
+			Command:  []string{"bundle", "exec", "sidekiq"},
+			Broker:   "redis",
+			Evidence: "Gemfile",
+		})
+	}
+
+	// ==== NODE ====
+	scripts := packageScripts(path)
+	for _, s := range npmProcessScripts {
+		if _, ok := scripts[s]; ok {
+			procs = append(procs, ProcessInfo{
+				Name:     s,
+				Kind:     "npm-script",
+				Command:  []string{"npm", "run", s},
+				Broker:   nodeQueueBroker(npm),
+				Evidence: "package.json scripts." + s,
+			})
+		}
+	}
+	if len(procs) == 0 && (npm("bullmq") || npm("bull")) {
+		if file := findBullWorker(path); file != "" {
+			procs = append(procs, ProcessInfo{
+				Name:     "worker",
+				Kind:     "bullmq",
+				Command:  []string{"node", file},
+				Broker:   "redis",
+				Evidence: file,
+			})
+		}
+	}
+
+	return dedupeProcessNames(procs)
+}
+
+// ---------------------------------------------------
+// PROCFILE
+// ---------------------------------------------------
+
+func procfileProcesses(path string) []ProcessInfo {
+	f, err := os.Open(filepath.Join(path, "Procfile"))
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var procs []ProcessInfo
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		m := procfileLine.FindStringSubmatch(line)
+		if m == nil || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name, cmd := m[1], strings.TrimSpace(m[2])
+		// web is the app service itself; release runs once, like migrate
+		if name == "web" || name == "release" {
+			continue
+		}
+
+		procs = append(procs, ProcessInfo{
+			Name: name,
+			Kind: "procfile",
+			// Procfile commands expect shell expansion of $VARS
+			Command:  []string{"sh", "-c", cmd},
+			Broker:   procfileBroker(cmd),
+			Evidence: "Procfile",
+		})
+	}
+	return procs
+}
+
+func procfileBroker(cmd string) string {
+	switch {
+	case strings.Contains(cmd, "sidekiq"), strings.Contains(cmd, "rq "):
+		return "redis"
+	case strings.Contains(cmd, "celery"):
+		return "celery"
+	}
+	return ""
+}
+
+// ---------------------------------------------------
+// HELPERS
+// ---------------------------------------------------
+
+// celeryBroker picks the broker Celery talks to: the scheme of a
+// configured broker URL, else the celery[redis] or celery[librabbitmq]
+// extra, else RabbitMQ when the repo uses it for other reasons, else
+// Redis. Brokers without a local service (SQS, filesystem) give "".
+func celeryBroker(path string) string {
+	if scheme := celeryBrokerScheme(path); scheme != "" {
+		return celeryBrokerSchemes[strings.ToLower(scheme)]
+	}
+
+	for _, name := range []string{"requirements.txt", "pyproject.toml"} {
+		data, _ := os.ReadFile(filepath.Join(path, name))
+		for _, m := range celeryExtras.FindAllStringSubmatch(string(data), -1) {
+			for _, extra := range strings.Split(m[1], ",") {
+				switch strings.TrimSpace(strings.ToLower(extra)) {
+				case "redis":
+					return "redis"
+				case "librabbitmq":
+					return "rabbitmq"
+				}
+			}
+		}
+	}
+
+	if HasDependency(DetectDependencies(path), "rabbitmq") {
+		return "rabbitmq"
+	}
+	return "redis"
+}
+
+// celeryBrokerScheme returns the URL scheme of the broker configured in
+// Python sources or .env files. docmake's own .env.docmake doesn't count.
+func celeryBrokerScheme(path string) string {
+	var scheme string
+
+	filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil || scheme != "" {
+			return nil
+		}
+		if info.IsDir() {
+			if skipDirs[info.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		name := info.Name()
+		if filepath.Ext(name) != ".py" && (!strings.HasPrefix(name, ".env") || name == ".env.docmake") {
+			return nil
+		}
+
+		content, _ := os.ReadFile(p)
+		if m := celeryBrokerURL.FindSubmatch(content); m != nil {
+			scheme = string(m[1])
+		}
+		return nil
+	})
+
+	return scheme
+}
+
+// findCeleryApp returns the -A argument for the module defining Celery()
+// and the file it was found in. Celery resolves "proj" to proj.celery.
+func findCeleryApp(path string) (string, string) {
+	var mod, file string
+
+	filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil || mod != "" {
+			return nil
+		}
+		if info.IsDir() {
+			if skipDirs[info.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(p) != ".py" {
+			return nil
+		}
+
+		content, _ := os.ReadFile(p)
+		if !celeryAppPattern.Match(content) {
+			return nil
+		}
+
+		rel, _ := filepath.Rel(path, p)
+		rel = filepath.ToSlash(strings.TrimSuffix(rel, ".py"))
+		file = rel + ".py"
+
+		if strings.HasSuffix(rel, "/celery") {
+			mod = strings.ReplaceAll(strings.TrimSuffix(rel, "/celery"), "/", ".")
+		} else {
+			mod = strings.ReplaceAll(rel, "/", ".")
+		}
+		return nil
+	})
+
+	return mod, file
+}
+
+func findBullWorker(path string) string {
+	var found string
+
+	filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil || found != "" {
+			return nil
+		}
+		if info.IsDir() {
+			if skipDirs[info.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if ext := filepath.Ext(p); ext != ".js" && ext != ".mjs" && ext != ".cjs" {
+			return nil
+		}
+
+		content, _ := os.ReadFile(p)
+		imports := strings.Join(jsImports(string(content)), " ")
+		if (strings.Contains(imports, "bullmq") || strings.Contains(imports, "bull")) && bullWorkerPattern.Match(content) {
+			rel, _ := filepath.Rel(path, p)
+			found = filepath.ToSlash(rel)
+		}
+		return nil
+	})
+
+	return found
+}
+
+func nodeQueueBroker(npm func(string) bool) string {
+	switch {
+	case npm("bullmq"), npm("bull"):
+		return "redis"
+	case npm("amqplib"):
+		return "rabbitmq"
+	case npm("kafkajs"):
+		return "kafka"
+	}
+	return ""
+}
+
+// repoMatches reports whether any file with the extension matches re
+func repoMatches(path string, re *regexp.Regexp, ext string) bool {
+	matched := false
+
+	filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil || matched {
+			return nil
+		}
+		if info.IsDir() {
+			if skipDirs[info.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(p) == ext {
+			content, _ := os.ReadFile(p)
+			matched = re.Match(content)
+		}
+		return nil
+	})
+
+	return matched
+}
+
+// dedupeProcessNames renames processes that would replace a generated
+// service (redis becomes redis-process) and suffixes repeated names
+// (worker, worker-2)
+func dedupeProcessNames(procs []ProcessInfo) []ProcessInfo {
+	seen := map[string]int{}
+	for i := range procs {
+		if reservedServiceNames[procs[i].Name] || isDependencyType(procs[i].Name) {
+			procs[i].Name += "-process"
+		}
+		seen[procs[i].Name]++
+		if n := seen[procs[i].Name]; n > 1 {
+			procs[i].Name = procs[i].Name + "-" + strconv.Itoa(n)
+		}
+	}
+	return procs
+}
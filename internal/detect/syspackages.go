@@ -0,0 +1,104 @@
+package detect
+
+// SystemPackages lists OS packages a language dependency needs. Build
+// packages are only installed in the builder stage, Runtime ones in the
+// final image.
+type SystemPackages struct {
+	Build   []string
+	Runtime []string
+
+	// Sources are the language packages that pulled these in
+	Sources []string
+}
+
+// osPackages maps one language package to its native requirements. Names
+// are Debian's: every generated Dockerfile builds on bookworm images.
+type osPackages struct {
+	AptBuild   []string
+	AptRuntime []string
+}
+
+var toolchainApt = []string{"build-essential", "pkg-config"}
+
+// nodeGypApt is what node-gyp needs to compile addons from source
+var nodeGypApt = []string{"build-essential", "python3"}
+
+var pypiNativePackages = map[string]osPackages{
+	"psycopg2": {
+		AptBuild: append([]string{"libpq-dev"}, toolchainApt...), AptRuntime: []string{"libpq5"},
+	},
+	"psycopg": {
+		AptRuntime: []string{"libpq5"},
+	},
+	"mysqlclient": {
+		AptBuild: append([]string{"default-libmysqlclient-dev"}, toolchainApt...), AptRuntime: []string{"libmariadb3"},
+	},
+	"pillow": {
+		AptBuild:   append([]string{"libjpeg62-turbo-dev", "zlib1g-dev", "libfreetype6-dev"}, toolchainApt...),
+		AptRuntime: []string{"libjpeg62-turbo", "zlib1g", "libfreetype6"},
+	},
+	"lxml": {
+		AptBuild: append([]string{"libxml2-dev", "libxslt1-dev"}, toolchainApt...), AptRuntime: []string{"libxml2", "libxslt1.1"},
+	},
+	"cffi": {
+		AptBuild: append([]string{"libffi-dev"}, toolchainApt...), AptRuntime: []string{"libffi8"},
+	},
+	"python-ldap": {
+		AptBuild: append([]string{"libldap2-dev", "libsasl2-dev"}, toolchainApt...), AptRuntime: []string{"libldap-2.5-0", "libsasl2-2"},
+	},
+	"pyodbc": {
+		AptBuild: append([]string{"unixodbc-dev"}, toolchainApt...), AptRuntime: []string{"unixodbc"},
+	},
+}
+
+var npmNativePackages = map[string]osPackages{
+	"bcrypt":         {AptBuild: nodeGypApt},
+	"argon2":         {AptBuild: nodeGypApt},
+	"sqlite3":        {AptBuild: nodeGypApt},
+	"better-sqlite3": {AptBuild: nodeGypApt},
+	"node-gyp":       {AptBuild: nodeGypApt},
+	"sharp": {
+		AptBuild: append([]string{"libvips-dev"}, nodeGypApt...), AptRuntime: []string{"libvips42"},
+	},
+	"canvas": {
+		AptBuild:   append([]string{"libcairo2-dev", "libpango1.0-dev", "libjpeg-dev", "libgif-dev", "librsvg2-dev"}, nodeGypApt...),
+		AptRuntime: []string{"libcairo2", "libpango-1.0-0", "libpangocairo-1.0-0", "libjpeg62-turbo", "libgif7", "librsvg2-2"},
+	},
+}
+
+// DetectSystemPackages returns the OS packages the repo's Python and Node
+// dependencies need to build and run on the Debian base images.
+func DetectSystemPackages(path string) SystemPackages {
+	m := ReadManifests(path)
+
+	build := map[string]bool{}
+	runtime := map[string]bool{}
+	sources := map[string]bool{}
+
+	add := func(name string, p osPackages) {
+		for _, pkg := range p.AptBuild {
+			build[pkg] = true
+		}
+		for _, pkg := range p.AptRuntime {
+			runtime[pkg] = true
+		}
+		sources[name] = true
+	}
+
+	for name, p := range pypiNativePackages {
+		if m.PyPI[name] {
+			add(name, p)
+		}
+	}
+	for name, p := range npmNativePackages {
+		if m.Npm[name] {
+			add(name, p)
+		}
+	}
+
+	return SystemPackages{
+		Build:   SortedKeys(build),
+		Runtime: SortedKeys(runtime),
+		Sources: SortedKeys(sources),
+	}
+}
@@ -0,0 +1,380 @@
+package docker
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/tejsvapandey1/docmake/internal/registry"
+)
+
+// ---------------------------------------------------
+// OCI ARTIFACTS
+// ---------------------------------------------------
+
+// OCI media types used for artifacts
+const (
+	OCIManifest    = "application/vnd.oci.image.manifest.v1+json"
+	OCIEmptyConfig = "application/vnd.oci.empty.v1+json"
+)
+
+// ErrReferrersUnsupported means the registry has no OCI referrers API, so
+// artifacts attached to an image couldn't be discovered from it
+var ErrReferrersUnsupported = errors.New("registry does not support the OCI referrers API")
+
+// Descriptor points at a blob or manifest in a registry
+type Descriptor struct {
+	MediaType    string            `json:"mediaType"`
+	Digest       string            `json:"digest"`
+	Size         int64             `json:"size"`
+	ArtifactType string            `json:"artifactType,omitempty"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+}
+
+// Manifest is an OCI image manifest, as used for artifacts
+type Manifest struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	MediaType     string            `json:"mediaType"`
+	ArtifactType  string            `json:"artifactType,omitempty"`
+	Config        Descriptor        `json:"config"`
+	Layers        []Descriptor      `json:"layers"`
+	Subject       *Descriptor       `json:"subject,omitempty"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+// PlatformManifest is one platform's image in an image index
+type PlatformManifest struct {
+	Platform string // os/arch[/variant]
+	Digest   string
+}
+
+// PlatformManifests lists the per-platform images of the index image
+// (repository@digest). Attestation manifests, whose platform is
+// unknown/unknown, are left out.
+func PlatformManifests(image string, auth AuthConfig) ([]PlatformManifest, error) {
+	ref, err := registry.ParseReference(image)
+	if err != nil {
+		return nil, err
+	}
+	if ref.Digest == "" {
+		return nil, fmt.Errorf("%s is not pinned to a digest", image)
+	}
+
+	s := newRegistrySession(ref, auth)
+	s.actions = "pull"
+
+	resp, err := s.do(http.MethodGet, "/manifests/"+ref.Digest, http.Header{"Accept": {manifestAccept}}, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("manifest %s: registry returned %s", ref.Digest, resp.Status)
+	}
+
+	var index struct {
+		Manifests []struct {
+			Digest   string `json:"digest"`
+			Platform *struct {
+				OS           string `json:"os"`
+				Architecture string `json:"architecture"`
+				Variant      string `json:"variant"`
+			} `json:"platform"`
+		} `json:"manifests"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxBlobSize)).Decode(&index); err != nil {
+		return nil, err
+	}
+
+	var out []PlatformManifest
+	for _, m := range index.Manifests {
+		p := m.Platform
+		if p == nil || p.OS == "unknown" {
+			continue
+		}
+		platform := p.OS + "/" + p.Architecture
+		if p.Variant != "" {
+			platform += "/" + p.Variant
+		}
+		out = append(out, PlatformManifest{Platform: platform, Digest: m.Digest})
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("%s is not a multi-platform image index", image)
+	}
+	return out, nil
+}
+
+// Artifact is a file to attach to an image
+type Artifact struct {
+	ArtifactType string // e.g. application/spdx+json
+	MediaType    string // of the file itself
+	Filename     string
+	Data         []byte
+}
+
+// AttachArtifact pushes a as an artifact manifest whose subject is image
+// (repository@digest), so registries list it as one of the image's
+// referrers. It returns the artifact manifest digest.
+func AttachArtifact(image string, a Artifact, auth AuthConfig) (string, error) {
+	ref, err := registry.ParseReference(image)
+	if err != nil {
+		return "", err
+	}
+	if ref.Digest == "" {
+		return "", fmt.Errorf("%s is not pinned to a digest", image)
+	}
+
+	s := newRegistrySession(ref, auth)
+
+	// Referrers API support is advertised by answering this endpoint
+	resp, err := s.do(http.MethodGet, "/referrers/"+ref.Digest, http.Header{"Accept": {"application/vnd.oci.image.index.v1+json"}}, nil)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", ErrReferrersUnsupported
+	}
+
+	subject, err := s.headManifest(ref.Digest)
+	if err != nil {
+		return "", err
+	}
+
+	config, err := s.pushBlob(OCIEmptyConfig, []byte("{}"))
+	if err != nil {
+		return "", err
+	}
+	layer, err := s.pushBlob(a.MediaType, a.Data)
+	if err != nil {
+		return "", err
+	}
+	layer.Annotations = map[string]string{"org.opencontainers.image.title": a.Filename}
+
+	manifest := Manifest{
+		SchemaVersion: 2,
+		MediaType:     OCIManifest,
+		ArtifactType:  a.ArtifactType,
+		Config:        config,
+		Layers:        []Descriptor{layer},
+		Subject:       &subject,
+		Annotations:   map[string]string{"org.opencontainers.image.created": time.Now().UTC().Format(time.RFC3339)},
+	}
+	return s.putManifest("", manifest)
+}
+
+// ---------------------------------------------------
+// REGISTRY SESSION
+// ---------------------------------------------------
+
+// maxBlobSize bounds the artifact blobs read back from a registry
+const maxBlobSize = 4 << 20
+
+// registrySession talks to one repository of the distribution API,
+// answering auth challenges with the given credentials
+type registrySession struct {
+	ref     registry.Reference
+	auth    AuthConfig
+	actions string // token scope actions, "pull,push" unless read-only
+	base    string // scheme://host/v2/<repository>
+	header  string // Authorization value once a challenge was answered
+}
+
+func newRegistrySession(ref registry.Reference, auth AuthConfig) *registrySession {
+	scheme := "https"
+	if ref.Insecure() {
+		scheme = "http"
+	}
+	return &registrySession{
+		ref:     ref,
+		auth:    auth,
+		actions: "pull,push",
+		base:    fmt.Sprintf("%s://%s/v2/%s", scheme, ref.APIHost(), ref.Repository),
+	}
+}
+
+// do sends a request below the repository (or to an absolute URL),
+// authenticating and retrying once when challenged
+func (s *registrySession) do(method, path string, header http.Header, body []byte) (*http.Response, error) {
+	target := path
+	if !strings.HasPrefix(path, "http://") && !strings.HasPrefix(path, "https://") {
+		target = s.base + path
+	}
+
+	send := func() (*http.Response, error) {
+		req, err := http.NewRequest(method, target, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		if s.header != "" {
+			req.Header.Set("Authorization", s.header)
+		}
+		return registryClient.Do(req)
+	}
+
+	resp, err := send()
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	resp.Body.Close()
+
+	challenge := resp.Header.Get("WWW-Authenticate")
+	if strings.HasPrefix(challenge, "Basic") {
+		if s.auth.Username == "" {
+			return nil, fmt.Errorf("%s requires credentials", s.ref.Registry)
+		}
+		s.header = "Basic " + basicAuth(s.auth.Username, s.auth.Password)
+	} else {
+		token, err := fetchToken(challenge, s.auth, "repository:"+s.ref.Repository+":"+s.actions)
+		if err != nil {
+			return nil, err
+		}
+		s.header = "Bearer " + token
+	}
+	return send()
+}
+
+// headManifest describes the manifest stored under reference
+func (s *registrySession) headManifest(reference string) (Descriptor, error) {
+	resp, err := s.do(http.MethodHead, "/manifests/"+reference, http.Header{"Accept": {manifestAccept}}, nil)
+	if err != nil {
+		return Descriptor{}, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Descriptor{}, fmt.Errorf("manifest %s: registry returned %s", reference, resp.Status)
+	}
+
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		digest = reference
+	}
+	return Descriptor{MediaType: resp.Header.Get("Content-Type"), Digest: digest, Size: resp.ContentLength}, nil
+}
+
+// getManifest fetches the OCI manifest stored under reference; found is
+// false when there is none
+func (s *registrySession) getManifest(reference string) (m Manifest, found bool, err error) {
+	resp, err := s.do(http.MethodGet, "/manifests/"+reference, http.Header{"Accept": {OCIManifest}}, nil)
+	if err != nil {
+		return m, false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return m, false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return m, false, fmt.Errorf("manifest %s: registry returned %s", reference, resp.Status)
+	}
+	return m, true, json.NewDecoder(resp.Body).Decode(&m)
+}
+
+// getBlob downloads a blob and checks it against its descriptor
+func (s *registrySession) getBlob(desc Descriptor) ([]byte, error) {
+	resp, err := s.do(http.MethodGet, "/blobs/"+desc.Digest, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("blob %s: registry returned %s", desc.Digest, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBlobSize))
+	if err != nil {
+		return nil, err
+	}
+	if digestOf(data) != desc.Digest {
+		return nil, fmt.Errorf("blob %s: content does not match its digest", desc.Digest)
+	}
+	return data, nil
+}
+
+// pushBlob uploads data unless the registry already has it
+func (s *registrySession) pushBlob(mediaType string, data []byte) (Descriptor, error) {
+	desc := Descriptor{MediaType: mediaType, Digest: digestOf(data), Size: int64(len(data))}
+
+	resp, err := s.do(http.MethodHead, "/blobs/"+desc.Digest, nil, nil)
+	if err != nil {
+		return desc, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return desc, nil
+	}
+
+	resp, err = s.do(http.MethodPost, "/blobs/uploads/", nil, nil)
+	if err != nil {
+		return desc, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return desc, fmt.Errorf("starting blob upload: registry returned %s", resp.Status)
+	}
+
+	location, err := resp.Request.URL.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		return desc, err
+	}
+	q := location.Query()
+	q.Set("digest", desc.Digest)
+	location.RawQuery = q.Encode()
+
+	resp, err = s.do(http.MethodPut, location.String(), http.Header{"Content-Type": {"application/octet-stream"}}, data)
+	if err != nil {
+		return desc, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return desc, fmt.Errorf("uploading blob: registry returned %s", resp.Status)
+	}
+	return desc, nil
+}
+
+// putManifest stores m under tag, or under its digest when tag is empty,
+// and returns the digest
+func (s *registrySession) putManifest(tag string, m Manifest) (string, error) {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return "", err
+	}
+	digest := digestOf(data)
+	if tag == "" {
+		tag = digest
+	}
+
+	resp, err := s.do(http.MethodPut, "/manifests/"+tag, http.Header{"Content-Type": {m.MediaType}}, data)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("pushing manifest: registry returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return digest, nil
+}
+
+// ---------------------------------------------------
+// HELPERS
+// ---------------------------------------------------
+
+func digestOf(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func basicAuth(user, password string) string {
+	return base64.StdEncoding.EncodeToString([]byte(user + ":" + password))
+}
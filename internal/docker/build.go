@@ -1,18 +1,55 @@
 package docker
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
 )
 
-func BuildImage(folderPath, imageName string) error {
-	fmt.Println("🐳 Building Docker image:", imageName)
+// buildKitOnly matches Dockerfile features the classic builder rejects
+var buildKitOnly = regexp.MustCompile(`(?m)^\s*RUN\s+--mount=|^\s*#\s*syntax=|^\s*(COPY|ADD)\s+.*--link\b`)
 
-	cmd := exec.Command("docker", "build", "-t", imageName, "-f", "Dockerfile", ".")
-	cmd.Dir = folderPath
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+// BuildImage builds the Dockerfile in folderPath for platform ("" for the
+// daemon's) with buildArgs, tagged with every name in imageNames
+func BuildImage(folderPath, platform string, buildArgs map[string]string, imageNames ...string) error {
+	fmt.Println("🐳 Building Docker image:", strings.Join(imageNames, ", "))
 
-	return cmd.Run()
+	client, err := NewClient()
+	if err != nil {
+		return err
+	}
+
+	// Cache mounts in the generated Dockerfiles need BuildKit
+	_, err = client.Build(context.Background(), folderPath, BuildOptions{
+		Tags:      imageNames,
+		BuildArgs: buildArgs,
+		Platform:  platform,
+		BuildKit:  requiresBuildKit(folderPath),
+		Progress:  PrintProgress(),
+	})
+	return err
+}
+
+// BuildArgFlags renders buildArgs as sorted --build-arg flags
+func BuildArgFlags(buildArgs map[string]string) []string {
+	keys := make([]string, 0, len(buildArgs))
+	for k := range buildArgs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var flags []string
+	for _, k := range keys {
+		flags = append(flags, "--build-arg", k+"="+buildArgs[k])
+	}
+	return flags
+}
+
+func requiresBuildKit(folderPath string) bool {
+	data, err := os.ReadFile(filepath.Join(folderPath, "Dockerfile"))
+	return err == nil && buildKitOnly.Match(data)
 }
@@ -0,0 +1,139 @@
+package docker
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ---------------------------------------------------
+// BUILDKIT PROGRESS
+// ---------------------------------------------------
+
+// buildkitTraceID marks stream messages whose aux is a base64 BuildKit
+// StatusResponse protobuf
+const buildkitTraceID = "moby.buildkit.trace"
+
+// Field numbers from BuildKit's control.proto
+const (
+	statusVertexes = 1 // StatusResponse.vertexes
+	statusLogs     = 3 // StatusResponse.logs
+	vertexDigest   = 1 // Vertex.digest
+	vertexName     = 3 // Vertex.name
+	vertexCached   = 4 // Vertex.cached
+	logMsg         = 4 // VertexLog.msg
+)
+
+var errMalformedTrace = errors.New("malformed BuildKit trace")
+
+// buildkitTrace renders a trace as output lines: each build step once,
+// by name, and the output of RUN steps. seen tracks printed steps.
+// Traces that don't decode are skipped; failures arrive as stream errors.
+func buildkitTrace(raw json.RawMessage, seen map[string]bool) []string {
+	var encoded string
+	if json.Unmarshal(raw, &encoded) != nil {
+		return nil
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil
+	}
+	fields, err := protoFields(data)
+	if err != nil {
+		return nil
+	}
+
+	var lines []string
+	for _, f := range fields {
+		switch f.num {
+		case statusVertexes:
+			vertex, err := protoFields(f.bytes)
+			if err != nil {
+				continue
+			}
+			var digest, name string
+			var cached bool
+			for _, vf := range vertex {
+				switch vf.num {
+				case vertexDigest:
+					digest = string(vf.bytes)
+				case vertexName:
+					name = string(vf.bytes)
+				case vertexCached:
+					cached = vf.varint != 0
+				}
+			}
+			if name == "" || seen[digest] {
+				continue
+			}
+			seen[digest] = true
+			if cached {
+				name += " (cached)"
+			}
+			lines = append(lines, fmt.Sprintf("=> %s\n", name))
+		case statusLogs:
+			entry, err := protoFields(f.bytes)
+			if err != nil {
+				continue
+			}
+			for _, lf := range entry {
+				if lf.num == logMsg {
+					lines = append(lines, string(lf.bytes))
+				}
+			}
+		}
+	}
+	return lines
+}
+
+// protoField is one decoded protobuf field; bytes holds length-delimited
+// payloads, varint the value of varint fields
+type protoField struct {
+	num    int
+	varint uint64
+	bytes  []byte
+}
+
+// protoFields splits a protobuf message into its top-level fields
+func protoFields(b []byte) ([]protoField, error) {
+	var fields []protoField
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			return nil, errMalformedTrace
+		}
+		b = b[n:]
+		f := protoField{num: int(key >> 3)}
+
+		switch key & 7 {
+		case 0: // varint
+			v, n := binary.Uvarint(b)
+			if n <= 0 {
+				return nil, errMalformedTrace
+			}
+			f.varint, b = v, b[n:]
+		case 1: // fixed64
+			if len(b) < 8 {
+				return nil, errMalformedTrace
+			}
+			b = b[8:]
+		case 2: // length-delimited
+			l, n := binary.Uvarint(b)
+			if n <= 0 || l > uint64(len(b)-n) {
+				return nil, errMalformedTrace
+			}
+			f.bytes, b = b[n:n+int(l)], b[n+int(l):]
+		case 5: // fixed32
+			if len(b) < 4 {
+				return nil, errMalformedTrace
+			}
+			b = b[4:]
+		default:
+			return nil, errMalformedTrace
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
@@ -0,0 +1,150 @@
+package docker
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// ---------------------------------------------------
+// MULTI-PLATFORM BUILDS (BUILDX)
+// ---------------------------------------------------
+
+// buildxBuilder is the builder instance docmake creates for multi-platform
+// builds: the default "docker" driver can't export image indexes
+const buildxBuilder = "docmake"
+
+// BuildxPublish builds the Dockerfile in folderPath with buildArgs for
+// every platform, pushes the result as one OCI image index under all imageNames and
+// returns the index digest. Multi-platform images can't be loaded into
+// the local image store, so building and pushing happen in one step.
+func BuildxPublish(folderPath string, platforms, imageNames []string, buildArgs map[string]string, auth AuthConfig) (string, error) {
+	// buildx pushes with the CLI's stored credentials, not the daemon's.
+	// Logging in under a throwaway DOCKER_CONFIG keeps credentials given
+	// on the command line out of ~/.docker/config.json.
+	var env []string
+	if auth.Username != "" && auth.Password != "" {
+		configDir, err := tempDockerConfig()
+		if err != nil {
+			return "", fmt.Errorf("preparing docker config: %w", err)
+		}
+		defer os.RemoveAll(configDir)
+		env = []string{"DOCKER_CONFIG=" + configDir}
+	}
+
+	if dockerCommand(env, "buildx", "version").Run() != nil {
+		return "", fmt.Errorf("multi-platform builds need the docker buildx plugin")
+	}
+	if dockerCommand(env, "buildx", "inspect", buildxBuilder).Run() != nil {
+		fmt.Println("🔧 Creating buildx builder:", buildxBuilder)
+		if err := runDocker("", "", env, "buildx", "create", "--name", buildxBuilder, "--driver", "docker-container"); err != nil {
+			return "", err
+		}
+	}
+
+	if env != nil {
+		host := RegistryHost(auth.ServerAddress)
+		if err := runDocker("", auth.Password, env, "login", "-u", auth.Username, "--password-stdin", host); err != nil {
+			return "", err
+		}
+	}
+
+	meta, err := os.CreateTemp("", "docmake-buildx-")
+	if err != nil {
+		return "", err
+	}
+	meta.Close()
+	defer os.Remove(meta.Name())
+
+	fmt.Printf("🐳 Building %s for %s\n", strings.Join(imageNames, ", "), strings.Join(platforms, ", "))
+
+	args := []string{"buildx", "build", "--builder", buildxBuilder,
+		"--platform", strings.Join(platforms, ","),
+		"--output", "type=registry,oci-mediatypes=true",
+		"--metadata-file", meta.Name()}
+	args = append(args, BuildArgFlags(buildArgs)...)
+	for _, name := range imageNames {
+		args = append(args, "-t", name)
+	}
+	args = append(args, "-f", "Dockerfile", ".")
+
+	if err := runDocker(folderPath, "", env, args...); err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile(meta.Name())
+	if err != nil {
+		return "", err
+	}
+	var out struct {
+		Digest string `json:"containerimage.digest"`
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return "", fmt.Errorf("reading buildx metadata: %w", err)
+	}
+	return out.Digest, nil
+}
+
+// tempDockerConfig creates an empty DOCKER_CONFIG directory that still
+// sees the user's CLI plugins, buildx builders and contexts, and carries
+// over the current context. The caller removes it.
+func tempDockerConfig() (string, error) {
+	userDir := os.Getenv("DOCKER_CONFIG")
+	if userDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		userDir = filepath.Join(home, ".docker")
+	}
+
+	dir, err := os.MkdirTemp("", "docmake-docker-config-")
+	if err != nil {
+		return "", err
+	}
+	for _, sub := range []string{"cli-plugins", "buildx", "contexts"} {
+		if _, err := os.Stat(filepath.Join(userDir, sub)); err == nil {
+			if err := os.Symlink(filepath.Join(userDir, sub), filepath.Join(dir, sub)); err != nil {
+				os.RemoveAll(dir)
+				return "", err
+			}
+		}
+	}
+
+	var user struct {
+		CurrentContext string `json:"currentContext,omitempty"`
+	}
+	if data, err := os.ReadFile(filepath.Join(userDir, "config.json")); err == nil {
+		json.Unmarshal(data, &user)
+	}
+	data, _ := json.Marshal(user)
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), data, 0600); err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	return dir, nil
+}
+
+// dockerCommand prepares a docker CLI call with extra environment
+func dockerCommand(env []string, args ...string) *exec.Cmd {
+	cmd := exec.Command("docker", args...)
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	return cmd
+}
+
+// runDocker runs the docker CLI in dir with output on the terminal
+func runDocker(dir, stdin string, env []string, args ...string) error {
+	cmd := dockerCommand(env, args...)
+	cmd.Dir = dir
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
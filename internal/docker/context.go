@@ -0,0 +1,223 @@
+package docker
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ---------------------------------------------------
+// .dockerignore MATCHING
+// ---------------------------------------------------
+
+type ignoreRule struct {
+	re     *regexp.Regexp
+	negate bool
+}
+
+// IgnoreMatcher evaluates .dockerignore rules the way the Docker CLI does:
+// rules apply in order, the last matching rule wins, and a rule matching a
+// parent directory excludes everything below it.
+type IgnoreMatcher struct {
+	rules []ignoreRule
+}
+
+// LoadDockerignore reads <dir>/.dockerignore. A missing file yields a
+// matcher that excludes nothing.
+func LoadDockerignore(dir string) (*IgnoreMatcher, error) {
+	m := &IgnoreMatcher{}
+
+	f, err := os.Open(filepath.Join(dir, ".dockerignore"))
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		rule := ignoreRule{}
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = strings.TrimSpace(line[1:])
+		}
+
+		line = filepath.ToSlash(filepath.Clean(line))
+		line = strings.TrimPrefix(line, "/")
+
+		re, err := regexp.Compile(ignorePatternToRegexp(line))
+		if err != nil {
+			return nil, fmt.Errorf("invalid .dockerignore pattern %q: %w", line, err)
+		}
+		rule.re = re
+		m.rules = append(m.rules, rule)
+	}
+
+	return m, scanner.Err()
+}
+
+// Excluded reports whether a slash-separated path relative to the context
+// root is left out of the build context.
+func (m *IgnoreMatcher) Excluded(rel string) bool {
+	rel = filepath.ToSlash(rel)
+
+	excluded := false
+	for _, r := range m.rules {
+		if r.matches(rel) {
+			excluded = !r.negate
+		}
+	}
+	return excluded
+}
+
+func (r ignoreRule) matches(rel string) bool {
+	if r.re.MatchString(rel) {
+		return true
+	}
+
+	// A pattern matching any parent directory matches the path as well
+	parts := strings.Split(rel, "/")
+	for i := 1; i < len(parts); i++ {
+		if r.re.MatchString(strings.Join(parts[:i], "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+func ignorePatternToRegexp(pattern string) string {
+	var sb strings.Builder
+	sb.WriteString("^")
+
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				// "**/" also matches zero directories
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					i++
+					sb.WriteString("(.*/)?")
+				} else {
+					sb.WriteString(".*")
+				}
+				continue
+			}
+			sb.WriteString("[^/]*")
+		case '?':
+			sb.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(pattern[i:], ']')
+			if end < 0 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			sb.WriteString(pattern[i : i+end+1])
+			i += end
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+				sb.WriteString(regexp.QuoteMeta(string(pattern[i])))
+			}
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	sb.WriteString("$")
+	return sb.String()
+}
+
+// ---------------------------------------------------
+// SECRET FILE GUARD
+// ---------------------------------------------------
+
+var secretFilePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`^\.env(\..+)?$`),
+	regexp.MustCompile(`\.(pem|key|p12|pfx|jks|keystore)$`),
+	regexp.MustCompile(`^id_(rsa|dsa|ecdsa|ed25519)(\.pub)?$`),
+	regexp.MustCompile(`^\.(npmrc|pypirc|netrc|htpasswd)$`),
+	regexp.MustCompile(`^(credentials|service-account|secrets?)\.(json|ya?ml)$`),
+}
+
+var allowedSecretLookalikes = map[string]bool{
+	".env.example":  true,
+	".env.sample":   true,
+	".env.template": true,
+}
+
+// SecretFilesInContext lists files under dir that look like secrets and are
+// not excluded by .dockerignore, i.e. files that `COPY . .` would bake into
+// the image.
+func SecretFilesInContext(dir string) ([]string, error) {
+	matcher, err := LoadDockerignore(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []string
+	err = filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, _ := filepath.Rel(dir, p)
+		if rel == "." {
+			return nil
+		}
+
+		if matcher.Excluded(rel) {
+			if info.IsDir() && !matcher.hasNegations() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if info.IsDir() {
+			if info.Name() == ".docmake" || info.Name() == ".ssh" || info.Name() == ".aws" {
+				found = append(found, filepath.ToSlash(rel)+"/")
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if isSecretFile(info.Name()) {
+			found = append(found, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+
+	return found, err
+}
+
+func (m *IgnoreMatcher) hasNegations() bool {
+	for _, r := range m.rules {
+		if r.negate {
+			return true
+		}
+	}
+	return false
+}
+
+func isSecretFile(name string) bool {
+	if allowedSecretLookalikes[name] {
+		return false
+	}
+	for _, re := range secretFilePatterns {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,146 @@
+package docker
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// ---------------------------------------------------
+// ~/.docker/config.json CREDENTIALS
+// ---------------------------------------------------
+
+// configFile is the part of the Docker CLI config holding credentials
+type configFile struct {
+	Auths map[string]struct {
+		Auth          string `json:"auth"`
+		IdentityToken string `json:"identitytoken"`
+	} `json:"auths"`
+	CredsStore  string            `json:"credsStore"`
+	CredHelpers map[string]string `json:"credHelpers"`
+}
+
+// ConfigPath returns $DOCKER_CONFIG/config.json or ~/.docker/config.json
+func ConfigPath() string {
+	dir := os.Getenv("DOCKER_CONFIG")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".docker")
+	}
+	return filepath.Join(dir, "config.json")
+}
+
+// StoredAuth returns the credentials the Docker CLI keeps for server (a
+// registry host, or DockerHubServer), checking credHelpers, inline auths
+// and credsStore in the order the CLI does. The second return value says
+// where they came from; ok is false when nothing usable is stored.
+func StoredAuth(server string) (auth AuthConfig, source string, ok bool, err error) {
+	data, err := os.ReadFile(ConfigPath())
+	if os.IsNotExist(err) {
+		return auth, "", false, nil
+	}
+	if err != nil {
+		return auth, "", false, err
+	}
+
+	var cfg configFile
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return auth, "", false, fmt.Errorf("parsing %s: %w", ConfigPath(), err)
+	}
+
+	keys := serverKeys(server)
+
+	// 1. Per-registry helper
+	for _, key := range keys {
+		if helper := cfg.CredHelpers[key]; helper != "" {
+			auth, err := helperGet(helper, key)
+			auth.ServerAddress = server
+			return auth, "docker-credential-" + helper, err == nil && auth.usable(), err
+		}
+	}
+
+	// 2. Inline auths (also where helpers leave empty placeholders)
+	for _, key := range keys {
+		entry, found := cfg.Auths[key]
+		if !found || (entry.Auth == "" && entry.IdentityToken == "") {
+			continue
+		}
+		auth = AuthConfig{ServerAddress: server, IdentityToken: entry.IdentityToken}
+		if entry.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+			if err != nil {
+				return auth, "", false, fmt.Errorf("invalid auth for %s in %s", key, ConfigPath())
+			}
+			auth.Username, auth.Password, _ = strings.Cut(string(decoded), ":")
+		}
+		return auth, ConfigPath(), auth.usable(), nil
+	}
+
+	// 3. Default store
+	if cfg.CredsStore != "" {
+		for _, key := range keys {
+			auth, err := helperGet(cfg.CredsStore, key)
+			if err == nil && auth.usable() {
+				auth.ServerAddress = server
+				return auth, "docker-credential-" + cfg.CredsStore, true, nil
+			}
+		}
+	}
+
+	return AuthConfig{}, "", false, nil
+}
+
+// usable reports whether the credentials can authenticate a push
+func (a AuthConfig) usable() bool {
+	return a.IdentityToken != "" || (a.Username != "" && a.Password != "")
+}
+
+// serverKeys lists the spellings a registry may be stored under
+func serverKeys(server string) []string {
+	if server == DockerHubServer || server == "docker.io" {
+		return []string{DockerHubServer, "index.docker.io", "docker.io", "https://index.docker.io/v1"}
+	}
+	host := RegistryHost(server)
+	return []string{host, "https://" + host, "http://" + host, "https://" + host + "/v1/", "https://" + host + "/v2/"}
+}
+
+// helperGet runs "docker-credential-<helper> get" with the server on stdin
+func helperGet(helper, server string) (AuthConfig, error) {
+	cmd := exec.Command("docker-credential-"+helper, "get")
+	cmd.Stdin = strings.NewReader(server)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stdout.String() + stderr.String())
+		if strings.Contains(msg, "credentials not found") {
+			return AuthConfig{}, nil
+		}
+		return AuthConfig{}, fmt.Errorf("docker-credential-%s: %v: %s", helper, err, msg)
+	}
+
+	var out struct {
+		Username string `json:"Username"`
+		Secret   string `json:"Secret"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		return AuthConfig{}, fmt.Errorf("docker-credential-%s: %w", helper, err)
+	}
+
+	auth := AuthConfig{ServerAddress: server}
+	// Helpers store identity tokens under the user name "<token>"
+	if out.Username == "<token>" {
+		auth.IdentityToken = out.Secret
+	} else {
+		auth.Username, auth.Password = out.Username, out.Secret
+	}
+	return auth, nil
+}
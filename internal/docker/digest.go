@@ -0,0 +1,155 @@
+package docker
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/tejsvapandey1/docmake/internal/registry"
+)
+
+// Manifest media types a tag can resolve to. Indexes come first so a
+// multi-arch tag is pinned as a whole, not to one platform.
+var manifestAccept = strings.Join([]string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}, ", ")
+
+var bearerParam = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+var registryClient = &http.Client{Timeout: 15 * time.Second}
+
+// ResolveDigest asks the image's registry for the digest a public tag
+// currently points at, using an anonymous pull token when required
+func ResolveDigest(ref string) (string, error) {
+	r, err := registry.ParseReference(ref)
+	if err != nil {
+		return "", err
+	}
+
+	scheme := "https"
+	if r.Insecure() {
+		scheme = "http"
+	}
+	manifestURL := fmt.Sprintf("%s://%s/v2/%s/manifests/%s", scheme, r.APIHost(), r.Repository, r.Tag)
+
+	resp, err := headManifest(manifestURL, "")
+	if err != nil {
+		return "", err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		token, err := fetchToken(resp.Header.Get("WWW-Authenticate"), AuthConfig{}, "")
+		if err != nil {
+			return "", err
+		}
+		if resp, err = headManifest(manifestURL, token); err != nil {
+			return "", err
+		}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("registry returned %s", resp.Status)
+	}
+
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if !strings.HasPrefix(digest, "sha256:") {
+		return "", fmt.Errorf("registry returned no digest")
+	}
+	return digest, nil
+}
+
+func headManifest(manifestURL, token string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodHead, manifestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", manifestAccept)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := registryClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return resp, nil
+}
+
+// fetchToken follows a Bearer challenge to the registry's token service.
+// Without credentials the token is anonymous; scope overrides the one in
+// the challenge when set.
+func fetchToken(challenge string, auth AuthConfig, scope string) (string, error) {
+	if !strings.HasPrefix(challenge, "Bearer ") {
+		return "", fmt.Errorf("unsupported registry auth challenge %q", challenge)
+	}
+
+	params := map[string]string{}
+	for _, m := range bearerParam.FindAllStringSubmatch(challenge, -1) {
+		params[m[1]] = m[2]
+	}
+	if params["realm"] == "" {
+		return "", fmt.Errorf("registry auth challenge has no realm")
+	}
+
+	if scope == "" {
+		scope = params["scope"]
+	}
+	q := url.Values{}
+	if params["service"] != "" {
+		q.Set("service", params["service"])
+	}
+	if scope != "" {
+		q.Set("scope", scope)
+	}
+
+	var req *http.Request
+	var err error
+	if auth.IdentityToken != "" {
+		// OAuth2 refresh token, as stored by "docker login" for some registries
+		q.Set("grant_type", "refresh_token")
+		q.Set("refresh_token", auth.IdentityToken)
+		q.Set("client_id", "docmake")
+		req, err = http.NewRequest(http.MethodPost, params["realm"], strings.NewReader(q.Encode()))
+		if req != nil {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+	} else {
+		req, err = http.NewRequest(http.MethodGet, params["realm"]+"?"+q.Encode(), nil)
+		if req != nil && auth.Username != "" {
+			req.SetBasicAuth(auth.Username, auth.Password)
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := registryClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token service returned %s", resp.Status)
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", err
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	return body.AccessToken, nil
+}
@@ -0,0 +1,475 @@
+package docker
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tejsvapandey1/docmake/internal/registry"
+)
+
+// ---------------------------------------------------
+// ENGINE API CLIENT
+// ---------------------------------------------------
+
+// DefaultAPIVersion is the Engine API version requests are pinned to
+const DefaultAPIVersion = "v1.43"
+
+// Client talks to the Docker Engine HTTP API. The zero value is not usable;
+// create one with NewClient or, against a fake server, NewClientWithHTTP.
+type Client struct {
+	HTTP       *http.Client
+	BaseURL    string // e.g. "http://docker" for the unix socket
+	APIVersion string
+}
+
+// NewClient connects to $DOCKER_HOST, or the default unix socket
+func NewClient() (*Client, error) {
+	host := os.Getenv("DOCKER_HOST")
+	if host == "" {
+		host = "unix:///var/run/docker.sock"
+	}
+
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DOCKER_HOST %q: %w", host, err)
+	}
+
+	switch u.Scheme {
+	case "unix":
+		socket := u.Path
+		transport := &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+		}
+		return NewClientWithHTTP("http://docker", &http.Client{Transport: transport}), nil
+	case "tcp", "http":
+		return NewClientWithHTTP("http://"+u.Host, http.DefaultClient), nil
+	}
+	return nil, fmt.Errorf("unsupported DOCKER_HOST scheme %q", u.Scheme)
+}
+
+// NewClientWithHTTP uses hc to reach the engine at baseURL
+func NewClientWithHTTP(baseURL string, hc *http.Client) *Client {
+	return &Client{HTTP: hc, BaseURL: strings.TrimSuffix(baseURL, "/"), APIVersion: DefaultAPIVersion}
+}
+
+// ---------------------------------------------------
+// ERRORS
+// ---------------------------------------------------
+
+// Error classes callers can test for with errors.Is
+var (
+	ErrDaemonUnavailable = errors.New("docker daemon unavailable")
+	ErrNotFound          = errors.New("not found")
+	ErrUnauthorized      = errors.New("unauthorized")
+	ErrBuildFailed       = errors.New("build failed")
+	ErrPushFailed        = errors.New("push failed")
+)
+
+// EngineError is an error reported by the engine, classified by Kind
+type EngineError struct {
+	Kind       error
+	StatusCode int
+	Message    string
+}
+
+func (e *EngineError) Error() string {
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("%v (HTTP %d): %s", e.Kind, e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("%v: %s", e.Kind, e.Message)
+}
+
+func (e *EngineError) Unwrap() error { return e.Kind }
+
+func classifyStatus(code int) error {
+	switch code {
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrUnauthorized
+	}
+	return errors.New("engine error")
+}
+
+// classifyMessage maps stream errors (which arrive with HTTP 200) to a class
+func classifyMessage(msg string, fallback error) error {
+	lower := strings.ToLower(msg)
+	switch {
+	case strings.Contains(lower, "unauthorized"), strings.Contains(lower, "denied"), strings.Contains(lower, "authentication required"):
+		return ErrUnauthorized
+	case strings.Contains(lower, "not found"), strings.Contains(lower, "does not exist"):
+		return ErrNotFound
+	}
+	return fallback
+}
+
+// ---------------------------------------------------
+// REQUESTS
+// ---------------------------------------------------
+
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, header http.Header, body io.Reader) (*http.Response, error) {
+	u := c.BaseURL + "/" + c.APIVersion + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, &EngineError{Kind: ErrDaemonUnavailable, Message: err.Error()}
+	}
+
+	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		var e struct {
+			Message string `json:"message"`
+		}
+		data, _ := io.ReadAll(resp.Body)
+		if json.Unmarshal(data, &e) != nil || e.Message == "" {
+			e.Message = strings.TrimSpace(string(data))
+		}
+		return nil, &EngineError{Kind: classifyStatus(resp.StatusCode), StatusCode: resp.StatusCode, Message: e.Message}
+	}
+
+	return resp, nil
+}
+
+// Ping checks that the daemon answers
+func (c *Client) Ping(ctx context.Context) error {
+	resp, err := c.do(ctx, http.MethodGet, "/_ping", nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// ---------------------------------------------------
+// PROGRESS STREAM
+// ---------------------------------------------------
+
+// JSONMessage is one line of the engine's build/push progress stream
+type JSONMessage struct {
+	Stream      string `json:"stream,omitempty"`
+	Status      string `json:"status,omitempty"`
+	ID          string `json:"id,omitempty"`
+	Progress    string `json:"progress,omitempty"`
+	Error       string `json:"error,omitempty"`
+	ErrorDetail *struct {
+		Message string `json:"message"`
+	} `json:"errorDetail,omitempty"`
+	Aux json.RawMessage `json:"aux,omitempty"`
+}
+
+// readStream decodes progress messages, hands each to progress and calls
+// aux for auxiliary payloads. Stream errors become EngineErrors of kind.
+func readStream(r io.Reader, kind error, progress func(JSONMessage), aux func(json.RawMessage)) error {
+	dec := json.NewDecoder(r)
+	for {
+		var msg JSONMessage
+		if err := dec.Decode(&msg); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if msg.Error != "" || msg.ErrorDetail != nil {
+			text := msg.Error
+			if msg.ErrorDetail != nil && msg.ErrorDetail.Message != "" {
+				text = msg.ErrorDetail.Message
+			}
+			return &EngineError{Kind: classifyMessage(text, kind), Message: text}
+		}
+		if len(msg.Aux) > 0 && aux != nil {
+			aux(msg.Aux)
+		}
+		if progress != nil {
+			progress(msg)
+		}
+	}
+}
+
+// PrintProgress is a progress callback writing build output and layer
+// status changes to stdout
+func PrintProgress() func(JSONMessage) {
+	last := map[string]string{}
+	seen := map[string]bool{}
+	return func(m JSONMessage) {
+		switch {
+		case m.ID == buildkitTraceID:
+			for _, line := range buildkitTrace(m.Aux, seen) {
+				fmt.Print(line)
+			}
+		case m.Stream != "":
+			fmt.Print(m.Stream)
+		case m.Status != "" && m.ID != "":
+			// Skip byte counters; print each layer state once
+			if last[m.ID] != m.Status {
+				last[m.ID] = m.Status
+				fmt.Printf("%s: %s\n", m.ID, m.Status)
+			}
+		case m.Status != "":
+			fmt.Println(m.Status)
+		}
+	}
+}
+
+// ---------------------------------------------------
+// AUTH
+// ---------------------------------------------------
+
+// AuthConfig holds registry credentials as the engine expects them
+type AuthConfig struct {
+	Username      string `json:"username,omitempty"`
+	Password      string `json:"password,omitempty"`
+	IdentityToken string `json:"identitytoken,omitempty"`
+	ServerAddress string `json:"serveraddress,omitempty"`
+}
+
+// DockerHubServer is the server address Docker Hub credentials belong to
+const DockerHubServer = registry.DockerHubServer
+
+// encode renders the X-Registry-Auth header value
+func (a AuthConfig) encode() string {
+	data, _ := json.Marshal(a)
+	return base64.URLEncoding.EncodeToString(data)
+}
+
+// Login validates credentials against the registry through the daemon
+func (c *Client) Login(ctx context.Context, auth AuthConfig) error {
+	data, _ := json.Marshal(auth)
+	header := http.Header{"Content-Type": {"application/json"}}
+
+	resp, err := c.do(ctx, http.MethodPost, "/auth", nil, header, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// ---------------------------------------------------
+// BUILD
+// ---------------------------------------------------
+
+// BuildOptions configures an engine build
+type BuildOptions struct {
+	Tags       []string
+	Dockerfile string // relative to the context, default "Dockerfile"
+	BuildArgs  map[string]string
+	Platform   string
+	BuildKit   bool // needed for RUN --mount and other BuildKit-only syntax
+	Progress   func(JSONMessage)
+}
+
+// Build sends dir as a tar context, honouring .dockerignore, and returns
+// the built image ID. BuildKit builds take the context from the request
+// body as well, so they need no session as long as the Dockerfile uses no
+// secret or SSH mounts.
+func (c *Client) Build(ctx context.Context, dir string, opts BuildOptions) (string, error) {
+	if opts.Dockerfile == "" {
+		opts.Dockerfile = "Dockerfile"
+	}
+
+	buildCtx, err := TarContext(dir)
+	if err != nil {
+		return "", err
+	}
+
+	q := url.Values{}
+	for _, t := range opts.Tags {
+		q.Add("t", t)
+	}
+	q.Set("dockerfile", filepath.ToSlash(opts.Dockerfile))
+	q.Set("rm", "1")
+	if opts.BuildKit {
+		q.Set("version", "2")
+	}
+	if opts.Platform != "" {
+		q.Set("platform", opts.Platform)
+	}
+	if len(opts.BuildArgs) > 0 {
+		args, _ := json.Marshal(opts.BuildArgs)
+		q.Set("buildargs", string(args))
+	}
+
+	header := http.Header{"Content-Type": {"application/x-tar"}}
+	resp, err := c.do(ctx, http.MethodPost, "/build", q, header, buildCtx)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var imageID string
+	err = readStream(resp.Body, ErrBuildFailed, opts.Progress, func(raw json.RawMessage) {
+		var aux struct {
+			ID string `json:"ID"`
+		}
+		if json.Unmarshal(raw, &aux) == nil && aux.ID != "" {
+			imageID = aux.ID
+		}
+	})
+	if err != nil {
+		return "", err
+	}
+	if imageID == "" {
+		return "", &EngineError{Kind: ErrBuildFailed, Message: "engine did not report an image ID"}
+	}
+	return imageID, nil
+}
+
+// TarContext archives dir for a build, leaving out what .dockerignore
+// excludes. The Dockerfile and .dockerignore are always sent.
+func TarContext(dir string) (*bytes.Buffer, error) {
+	ignore, err := LoadDockerignore(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+
+	err = filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, p)
+		if rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+
+		if ignore.Excluded(rel) && rel != "Dockerfile" && rel != ".dockerignore" {
+			// Re-included paths below an excluded directory need the walk
+			if info.IsDir() && !ignore.hasNegations() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(p); err != nil {
+				return err
+			}
+		}
+
+		hdr, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		hdr.Name = rel
+		if info.IsDir() {
+			hdr.Name += "/"
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+
+		if info.Mode().IsRegular() {
+			f, err := os.Open(p)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			if _, err := io.Copy(tw, f); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return buf, tw.Close()
+}
+
+// ---------------------------------------------------
+// PUSH
+// ---------------------------------------------------
+
+// Push uploads image (name:tag) and returns the manifest digest the
+// registry reported
+func (c *Client) Push(ctx context.Context, image string, auth AuthConfig, progress func(JSONMessage)) (string, error) {
+	ref, err := registry.ParseReference(image)
+	if err != nil {
+		return "", err
+	}
+	name := ref.WithTag("").Familiar()
+
+	q := url.Values{"tag": {ref.Tag}}
+	header := http.Header{"X-Registry-Auth": {auth.encode()}}
+
+	resp, err := c.do(ctx, http.MethodPost, "/images/"+name+"/push", q, header, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var digest string
+	err = readStream(resp.Body, ErrPushFailed, progress, func(raw json.RawMessage) {
+		var aux struct {
+			Digest string `json:"Digest"`
+		}
+		if json.Unmarshal(raw, &aux) == nil && aux.Digest != "" {
+			digest = aux.Digest
+		}
+	})
+	return digest, err
+}
+
+// ---------------------------------------------------
+// INSPECT
+// ---------------------------------------------------
+
+// ImageInfo is the subset of image inspect docmake uses
+type ImageInfo struct {
+	ID           string   `json:"Id"`
+	RepoTags     []string `json:"RepoTags"`
+	RepoDigests  []string `json:"RepoDigests"`
+	Architecture string   `json:"Architecture"`
+	Os           string   `json:"Os"`
+	Size         int64    `json:"Size"`
+	Config       struct {
+		Labels       map[string]string   `json:"Labels"`
+		ExposedPorts map[string]struct{} `json:"ExposedPorts"`
+		User         string              `json:"User"`
+	} `json:"Config"`
+}
+
+// ImageInspect returns metadata of a local image
+func (c *Client) ImageInspect(ctx context.Context, ref string) (ImageInfo, error) {
+	var info ImageInfo
+
+	resp, err := c.do(ctx, http.MethodGet, "/images/"+ref+"/json", nil, nil, nil)
+	if err != nil {
+		return info, err
+	}
+	defer resp.Body.Close()
+
+	err = json.NewDecoder(resp.Body).Decode(&info)
+	return info, err
+}
@@ -0,0 +1,307 @@
+package docker
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// fakeEngine serves handler under the pinned API version
+func fakeEngine(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	srv := httptest.NewServer(http.StripPrefix("/"+DefaultAPIVersion, handler))
+	t.Cleanup(srv.Close)
+	return NewClientWithHTTP(srv.URL, srv.Client())
+}
+
+func writeStream(w http.ResponseWriter, msgs ...any) {
+	enc := json.NewEncoder(w)
+	for _, m := range msgs {
+		enc.Encode(m)
+	}
+}
+
+func TestLogin(t *testing.T) {
+	var got AuthConfig
+	c := fakeEngine(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/auth" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		if got.Password != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			writeStream(w, map[string]string{"message": "incorrect username or password"})
+			return
+		}
+		writeStream(w, map[string]string{"Status": "Login Succeeded"})
+	})
+
+	auth := AuthConfig{Username: "me", Password: "s3cret", ServerAddress: DockerHubServer}
+	if err := c.Login(context.Background(), auth); err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if got != auth {
+		t.Errorf("engine got %+v, want %+v", got, auth)
+	}
+
+	auth.Password = "wrong"
+	err := c.Login(context.Background(), auth)
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("Login with a wrong password = %v, want ErrUnauthorized", err)
+	}
+	if !strings.Contains(err.Error(), "incorrect username or password") {
+		t.Errorf("error %q lost the engine message", err)
+	}
+}
+
+func TestLoginDaemonUnavailable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	c := NewClientWithHTTP(srv.URL, srv.Client())
+	if err := c.Login(context.Background(), AuthConfig{}); !errors.Is(err, ErrDaemonUnavailable) {
+		t.Fatalf("Login against a closed server = %v, want ErrDaemonUnavailable", err)
+	}
+}
+
+func TestPush(t *testing.T) {
+	c := fakeEngine(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/images/me/app/push" || r.URL.Query().Get("tag") != "1.0" {
+			t.Errorf("unexpected request %s?%s", r.URL.Path, r.URL.RawQuery)
+		}
+
+		data, _ := base64.URLEncoding.DecodeString(r.Header.Get("X-Registry-Auth"))
+		var auth AuthConfig
+		json.Unmarshal(data, &auth)
+		if auth.Username != "me" {
+			writeStream(w, JSONMessage{Status: "The push refers to repository [docker.io/me/app]"},
+				map[string]string{"error": "denied: requested access to the resource is denied"})
+			return
+		}
+
+		writeStream(w,
+			JSONMessage{Status: "Preparing", ID: "a1b2"},
+			JSONMessage{Status: "Pushed", ID: "a1b2"},
+			map[string]any{"aux": map[string]any{"Tag": "1.0", "Digest": "sha256:abc", "Size": 525}},
+		)
+	})
+
+	var statuses []string
+	progress := func(m JSONMessage) { statuses = append(statuses, m.Status) }
+
+	digest, err := c.Push(context.Background(), "me/app:1.0", AuthConfig{Username: "me"}, progress)
+	if err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+	if digest != "sha256:abc" {
+		t.Errorf("digest = %q, want sha256:abc", digest)
+	}
+	if len(statuses) != 3 || statuses[1] != "Pushed" {
+		t.Errorf("progress saw %q", statuses)
+	}
+
+	_, err = c.Push(context.Background(), "me/app:1.0", AuthConfig{Username: "someone"}, nil)
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("denied push = %v, want ErrUnauthorized", err)
+	}
+}
+
+// buildDir writes a context with a Dockerfile, a source file and an
+// ignored file
+func buildDir(t *testing.T, dockerfile string) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"Dockerfile":    dockerfile,
+		".dockerignore": "*.log\n",
+		"main.go":       "package main\n",
+		"debug.log":     "noise\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// tarNames lists the entries of a build context
+func tarNames(t *testing.T, r io.Reader) []string {
+	t.Helper()
+	var names []string
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("reading context: %v", err)
+		}
+		names = append(names, hdr.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestBuild(t *testing.T) {
+	dir := buildDir(t, "FROM scratch\nCOPY main.go /\n")
+
+	c := fakeEngine(t, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if got := q["t"]; len(got) != 2 || got[0] != "me/app:1.0" || got[1] != "me/app:latest" {
+			t.Errorf("tags = %q", got)
+		}
+		if q.Get("version") != "" {
+			t.Errorf("classic build sent version=%s", q.Get("version"))
+		}
+		var args map[string]string
+		json.Unmarshal([]byte(q.Get("buildargs")), &args)
+		if args["OCI_REVISION"] != "abc123" {
+			t.Errorf("buildargs = %v", args)
+		}
+		if got := strings.Join(tarNames(t, r.Body), ","); got != ".dockerignore,Dockerfile,main.go" {
+			t.Errorf("context = %s", got)
+		}
+
+		writeStream(w,
+			JSONMessage{Stream: "Step 1/2 : FROM scratch\n"},
+			map[string]any{"aux": map[string]string{"ID": "sha256:feed"}},
+			JSONMessage{Stream: "Successfully built feed\n"},
+		)
+	})
+
+	id, err := c.Build(context.Background(), dir, BuildOptions{
+		Tags:      []string{"me/app:1.0", "me/app:latest"},
+		BuildArgs: map[string]string{"OCI_REVISION": "abc123"},
+	})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if id != "sha256:feed" {
+		t.Errorf("image ID = %q, want sha256:feed", id)
+	}
+}
+
+// protoBytes encodes one length-delimited protobuf field
+func protoBytes(num int, data []byte) []byte {
+	b := binary.AppendUvarint(nil, uint64(num)<<3|2)
+	b = binary.AppendUvarint(b, uint64(len(data)))
+	return append(b, data...)
+}
+
+func TestBuildKit(t *testing.T) {
+	dir := buildDir(t, "# syntax=docker/dockerfile:1\nFROM scratch\nRUN --mount=type=cache,target=/c true\n")
+	if !requiresBuildKit(dir) {
+		t.Fatal("cache mount not detected as BuildKit-only")
+	}
+
+	vertex := append(protoBytes(vertexDigest, []byte("sha256:v1")), protoBytes(vertexName, []byte("[1/2] FROM scratch"))...)
+	logEntry := append(protoBytes(1, []byte("sha256:v1")), protoBytes(logMsg, []byte("compiling\n"))...)
+	trace := base64.StdEncoding.EncodeToString(append(protoBytes(statusVertexes, vertex), protoBytes(statusLogs, logEntry)...))
+
+	c := fakeEngine(t, func(w http.ResponseWriter, r *http.Request) {
+		if v := r.URL.Query().Get("version"); v != "2" {
+			t.Errorf("version = %q, want 2", v)
+		}
+		writeStream(w,
+			map[string]any{"id": buildkitTraceID, "aux": trace},
+			map[string]any{"id": buildkitTraceID, "aux": trace},
+			map[string]any{"id": "moby.image.id", "aux": map[string]string{"ID": "sha256:beef"}},
+		)
+	})
+
+	var lines []string
+	seen := map[string]bool{}
+	progress := func(m JSONMessage) {
+		if m.ID == buildkitTraceID {
+			lines = append(lines, buildkitTrace(m.Aux, seen)...)
+		}
+	}
+
+	id, err := c.Build(context.Background(), dir, BuildOptions{BuildKit: true, Progress: progress})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if id != "sha256:beef" {
+		t.Errorf("image ID = %q, want sha256:beef", id)
+	}
+
+	// The repeated vertex is printed once, its log every time
+	want := "=> [1/2] FROM scratch\n|compiling\n|compiling\n"
+	if got := strings.Join(lines, "|"); got != want {
+		t.Errorf("progress = %q, want %q", got, want)
+	}
+}
+
+func TestBuildFailure(t *testing.T) {
+	dir := buildDir(t, "FROM scratch\nRUN false\n")
+
+	c := fakeEngine(t, func(w http.ResponseWriter, r *http.Request) {
+		writeStream(w,
+			JSONMessage{Stream: "Step 2/2 : RUN false\n"},
+			map[string]any{
+				"error":       "The command '/bin/sh -c false' returned a non-zero code: 1",
+				"errorDetail": map[string]any{"message": "The command '/bin/sh -c false' returned a non-zero code: 1"},
+			},
+		)
+	})
+
+	_, err := c.Build(context.Background(), dir, BuildOptions{})
+	if !errors.Is(err, ErrBuildFailed) {
+		t.Fatalf("Build = %v, want ErrBuildFailed", err)
+	}
+}
+
+func TestImageInspect(t *testing.T) {
+	c := fakeEngine(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/images/me/app:1.0/json" {
+			w.WriteHeader(http.StatusNotFound)
+			writeStream(w, map[string]string{"message": "No such image: " + strings.TrimPrefix(r.URL.Path, "/images/")})
+			return
+		}
+		writeStream(w, map[string]any{
+			"Id":           "sha256:feed",
+			"RepoTags":     []string{"me/app:1.0"},
+			"RepoDigests":  []string{"me/app@sha256:abc"},
+			"Architecture": "arm64",
+			"Os":           "linux",
+			"Size":         1234,
+			"Config": map[string]any{
+				"User":         "10001:10001",
+				"Labels":       map[string]string{"io.docmake.stack": "go"},
+				"ExposedPorts": map[string]any{"8080/tcp": map[string]any{}},
+			},
+		})
+	})
+
+	info, err := c.ImageInspect(context.Background(), "me/app:1.0")
+	if err != nil {
+		t.Fatalf("ImageInspect: %v", err)
+	}
+	if info.ID != "sha256:feed" || info.Architecture != "arm64" || info.Size != 1234 {
+		t.Errorf("info = %+v", info)
+	}
+	if info.Config.User != "10001:10001" || info.Config.Labels["io.docmake.stack"] != "go" {
+		t.Errorf("config = %+v", info.Config)
+	}
+	if _, ok := info.Config.ExposedPorts["8080/tcp"]; !ok {
+		t.Errorf("exposed ports = %v", info.Config.ExposedPorts)
+	}
+
+	_, err = c.ImageInspect(context.Background(), "me/missing:1.0")
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("missing image = %v, want ErrNotFound", err)
+	}
+}
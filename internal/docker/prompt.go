@@ -10,17 +10,19 @@ import (
 	"golang.org/x/term"
 )
 
-func PromptForCredentials() (string, string, error) {
+// PromptForCredentials asks for a user name and a hidden password for the
+// registry called label
+func PromptForCredentials(label string) (string, string, error) {
 	reader := bufio.NewReader(os.Stdin)
 
-	fmt.Print("Enter Docker Hub username: ")
+	fmt.Printf("Enter %s username: ", label)
 	username, err := reader.ReadString('\n')
 	if err != nil {
 		return "", "", err
 	}
 	username = username[:len(username)-1] // trim newline
 
-	fmt.Print("Enter Docker Hub password: ")
+	fmt.Printf("Enter %s password: ", label)
 	bytePassword, err := term.ReadPassword(int(syscall.Stdin))
 	if err != nil {
 		return "", "", err
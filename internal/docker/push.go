@@ -1,34 +1,44 @@
 package docker
 
 import (
+	"context"
 	"fmt"
-	"os"
-	"os/exec"
 	"strings"
+
+	"github.com/tejsvapandey1/docmake/internal/registry"
 )
 
-func Login(username, password string) error {
-	if username == "" || password == "" {
-		fmt.Println("⚠️  Docker Hub login skipped (credentials missing).")
+func Login(auth AuthConfig) error {
+	if auth.Username == "" || (auth.Password == "" && auth.IdentityToken == "") {
+		fmt.Println("⚠️  Registry login skipped (credentials missing).")
 		return nil
 	}
 
-	fmt.Println("🔐 Logging into Docker Hub...")
-
-	cmd := exec.Command("docker", "login", "-u", username, "--password-stdin")
-	cmd.Stdin = strings.NewReader(password)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	fmt.Printf("🔐 Logging into %s...\n", registry.Label(RegistryHost(auth.ServerAddress)))
 
-	return cmd.Run()
+	client, err := NewClient()
+	if err != nil {
+		return err
+	}
+	return client.Login(context.Background(), auth)
 }
 
-func PushImage(imageName string) error {
+// PushImage pushes imageName and returns the digest the registry assigned
+func PushImage(imageName string, auth AuthConfig) (string, error) {
 	fmt.Println("📤 Pushing image:", imageName)
 
-	cmd := exec.Command("docker", "push", imageName)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	client, err := NewClient()
+	if err != nil {
+		return "", err
+	}
+	return client.Push(context.Background(), imageName, auth, PrintProgress())
+}
 
-	return cmd.Run()
+// RegistryHost turns a credential server address into a registry host
+func RegistryHost(server string) string {
+	if server == "" || server == DockerHubServer {
+		return registry.DockerHub
+	}
+	server = strings.TrimPrefix(strings.TrimPrefix(server, "https://"), "http://")
+	return strings.SplitN(server, "/", 2)[0]
 }
@@ -0,0 +1,152 @@
+package docker
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/tejsvapandey1/docmake/internal/registry"
+)
+
+// ---------------------------------------------------
+// COSIGN SIGNATURES
+// ---------------------------------------------------
+
+// Cosign stores the signatures of sha256:<hex> as layers of an OCI
+// manifest tagged sha256-<hex>.sig in the image's repository; each layer
+// is a simple signing payload with the signature in an annotation
+const (
+	SimpleSigningMediaType = "application/vnd.dev.cosign.simplesigning.v1+json"
+	SignatureAnnotation    = "dev.cosignproject.cosign/signature"
+	ociImageConfig         = "application/vnd.oci.image.config.v1+json"
+)
+
+// Signature is one signed payload
+type Signature struct {
+	Payload []byte
+	Value   string // base64 signature of Payload
+}
+
+// SignatureTag is the tag cosign stores the signatures of digest under
+func SignatureTag(digest string) string {
+	return strings.Replace(digest, ":", "-", 1) + ".sig"
+}
+
+// PinnedReference resolves image to its manifest digest, asking the
+// registry when it is only tagged
+func PinnedReference(image string, auth AuthConfig) (registry.Reference, error) {
+	ref, err := registry.ParseReference(image)
+	if err != nil || ref.Digest != "" {
+		return ref, err
+	}
+
+	s := newRegistrySession(ref, auth)
+	s.actions = "pull"
+	desc, err := s.headManifest(ref.Tag)
+	if err != nil {
+		return ref, err
+	}
+	if !strings.HasPrefix(desc.Digest, "sha256:") {
+		return ref, fmt.Errorf("registry returned no digest for %s", image)
+	}
+	return ref.WithDigest(desc.Digest), nil
+}
+
+// PushSignature adds sig to the signatures of image (repository@digest),
+// keeping the ones already there, and returns the signature manifest
+// digest
+func PushSignature(image string, sig Signature, auth AuthConfig) (string, error) {
+	ref, err := registry.ParseReference(image)
+	if err != nil {
+		return "", err
+	}
+	if ref.Digest == "" {
+		return "", fmt.Errorf("%s is not pinned to a digest", image)
+	}
+
+	s := newRegistrySession(ref, auth)
+	tag := SignatureTag(ref.Digest)
+
+	existing, _, err := s.getManifest(tag)
+	if err != nil {
+		return "", err
+	}
+
+	layer, err := s.pushBlob(SimpleSigningMediaType, sig.Payload)
+	if err != nil {
+		return "", err
+	}
+	layer.Annotations = map[string]string{SignatureAnnotation: sig.Value}
+
+	var layers []Descriptor
+	for _, l := range existing.Layers {
+		if l.Digest != layer.Digest || l.Annotations[SignatureAnnotation] != sig.Value {
+			layers = append(layers, l)
+		}
+	}
+	layers = append(layers, layer)
+
+	config, err := s.pushBlob(ociImageConfig, signatureConfig(layers))
+	if err != nil {
+		return "", err
+	}
+
+	return s.putManifest(tag, Manifest{
+		SchemaVersion: 2,
+		MediaType:     OCIManifest,
+		Config:        config,
+		Layers:        layers,
+	})
+}
+
+// Signatures fetches the signatures stored for image (repository@digest);
+// an unsigned image has none
+func Signatures(image string, auth AuthConfig) ([]Signature, error) {
+	ref, err := registry.ParseReference(image)
+	if err != nil {
+		return nil, err
+	}
+	if ref.Digest == "" {
+		return nil, fmt.Errorf("%s is not pinned to a digest", image)
+	}
+
+	s := newRegistrySession(ref, auth)
+	s.actions = "pull"
+
+	m, found, err := s.getManifest(SignatureTag(ref.Digest))
+	if err != nil || !found {
+		return nil, err
+	}
+
+	var sigs []Signature
+	for _, l := range m.Layers {
+		value := l.Annotations[SignatureAnnotation]
+		if l.MediaType != SimpleSigningMediaType || value == "" {
+			continue
+		}
+		payload, err := s.getBlob(l)
+		if err != nil {
+			return nil, err
+		}
+		sigs = append(sigs, Signature{Payload: payload, Value: value})
+	}
+	return sigs, nil
+}
+
+// signatureConfig is the image config cosign writes for a signature
+// manifest: no platform, one diff ID per payload layer
+func signatureConfig(layers []Descriptor) []byte {
+	diffIDs := []string{}
+	for _, l := range layers {
+		diffIDs = append(diffIDs, l.Digest)
+	}
+
+	config := map[string]interface{}{
+		"architecture": "",
+		"os":           "",
+		"config":       map[string]interface{}{},
+		"rootfs":       map[string]interface{}{"type": "layers", "diff_ids": diffIDs},
+	}
+	data, _ := json.Marshal(config)
+	return data
+}
@@ -9,32 +9,66 @@ import (
 	"github.com/tejsvapandey1/docmake/internal/detect"
 )
 
+// ComposeOptions tunes the generated docker-compose.yml
+type ComposeOptions struct {
+	// PublishServicePorts exposes database and broker ports on the host.
+	// Off by default: the app reaches them over the compose network.
+	PublishServicePorts bool
+
+	// Seed adds a one-shot service loading fixtures after migrations
+	Seed bool
+
+	// Hardening selects the security settings of app-image services
+	Hardening Hardening
+}
+
 // ---------------------------------------------------
 // SINGLE SERVICE COMPOSE GENERATOR
 // ---------------------------------------------------
 
-func GenerateComposeFile(path string, stack *detect.TechStack, meta detect.ProjectMeta, imageName string) error {
+func GenerateComposeFile(path string, stack *detect.TechStack, meta detect.ProjectMeta, imageName string, opts ComposeOptions) error {
 	filePath := filepath.Join(path, "docker-compose.yml")
 
 	var appService string
 
 	switch stack.Primary {
 	case "node":
-		appService = composeNode(meta, imageName)
+		appService = composeNode(meta, imageName, opts)
 	case "python":
 		if meta.Framework == "django" {
-			appService = composeDjango(meta, imageName)
+			appService = composeDjango(meta, imageName, opts)
 		} else {
-			appService = composePython(meta, imageName)
+			appService = composePython(meta, imageName, opts)
 		}
 	case "go":
-		appService = composeGo(meta, imageName)
+		appService = composeGo(meta, imageName, opts)
+	case "rust":
+		appService = composeBinary("rust_app", meta, imageName, opts)
+	case "java-maven":
+		appService = composeBinary("java_app", meta, imageName, opts)
 	default:
 		return fmt.Errorf("unsupported tech stack: %s", stack.Primary)
 	}
 
+	// Private keys are mounted as compose secrets instead of env values
+	if err := writeSecretFiles(path, meta); err != nil {
+		return err
+	}
+
+	// Generated credentials shared by the app and its backing services
+	if err := writeLocalEnv(path, meta); err != nil {
+		return err
+	}
+
+	// One-shot migrate/seed services sharing the app image
+	appService += composeMigrations(path, meta, imageName, opts)
+
+	// Workers and schedulers sharing the app image
+	appService += composeProcesses(meta, imageName, opts)
+
 	// Add DB service
-	completeCompose := composeWithDB(appService, meta.Database)
+	completeCompose := composeWithDB(appService, meta, opts)
+	completeCompose += topLevelSecretsBlock(meta)
 
 	return os.WriteFile(filePath, []byte(completeCompose), 0644)
 }
@@ -55,206 +89,507 @@ func GenerateMultiCompose(path string, frontend detect.ProjectMeta, backend dete
 // NODE.JS SERVICE
 // ---------------------------------------------------
 
-func composeNode(meta detect.ProjectMeta, imageName string) string {
-	envBlock := buildEnvBlock(meta)
-
-	envFileLine := ""
-	if meta.EnvFilePath != "" {
-		envFileLine = fmt.Sprintf("    env_file:\n      - %s\n", meta.EnvFilePath)
-	}
+func composeNode(meta detect.ProjectMeta, imageName string, opts ComposeOptions) string {
+	envSection := appEnvSection(meta)
 
-	envSection := envFileLine
-	if envFileLine == "" && envBlock != "" {
-		envSection = fmt.Sprintf("    environment:\n%s", envBlock)
+	// Next.js has no entry file; it starts through its npm script
+	command := []string{"node", meta.EntryFile}
+	if meta.Framework == "nextjs" {
+		command = []string{"npm", "start"}
 	}
 
 	return fmt.Sprintf(`
-version: '3.9'
-
 services:
   app:
     image: %s
     container_name: node_app
     ports:
       - "%s:%s"
-%s    command: ["node", "%s"]
-`, imageName, meta.Port, meta.Port, envSection, meta.EntryFile)
+%s%s%s    command: %s
+`, imageName, meta.Port, meta.Port, envSection, appExtras(meta, opts), appHealthcheck("node", meta), flowSeq(command))
 }
 
 // ---------------------------------------------------
 // PYTHON SERVICE
 // ---------------------------------------------------
 
-func composePython(meta detect.ProjectMeta, imageName string) string {
-	envBlock := buildEnvBlock(meta)
-
-	envFileLine := ""
-	if meta.EnvFilePath != "" {
-		envFileLine = fmt.Sprintf("    env_file:\n      - %s\n", meta.EnvFilePath)
-	}
-
-	envSection := envFileLine
-	if envFileLine == "" && envBlock != "" {
-		envSection = fmt.Sprintf("    environment:\n%s", envBlock)
-	}
+func composePython(meta detect.ProjectMeta, imageName string, opts ComposeOptions) string {
+	envSection := appEnvSection(meta)
 
 	return fmt.Sprintf(`
-version: '3.9'
-
 services:
   app:
     image: %s
     container_name: python_app
     ports:
       - "%s:%s"
-%s    command: ["python", "%s"]
-`, imageName, meta.Port, meta.Port, envSection, meta.EntryFile)
+%s%s%s    command: ["python", "%s"]
+`, imageName, meta.Port, meta.Port, envSection, appExtras(meta, opts), appHealthcheck("python", meta), meta.EntryFile)
 }
 
 // ---------------------------------------------------
 // DJANGO SERVICE
 // ---------------------------------------------------
 
-func composeDjango(meta detect.ProjectMeta, imageName string) string {
-	envBlock := buildEnvBlock(meta)
-
-	envFileLine := ""
-	if meta.EnvFilePath != "" {
-		envFileLine = fmt.Sprintf("    env_file:\n      - %s\n", meta.EnvFilePath)
-	}
+func composeDjango(meta detect.ProjectMeta, imageName string, opts ComposeOptions) string {
+	envSection := appEnvSection(meta)
 
-	envSection := envFileLine
-	if envFileLine == "" && envBlock != "" {
-		envSection = fmt.Sprintf("    environment:\n%s", envBlock)
+	// Without a migrate service, migrations run in the app container
+	command := `sh -c "python manage.py migrate &&
+             gunicorn project.wsgi:application --bind 0.0.0.0:8000"`
+	if hasMigrateService(meta) {
+		command = `gunicorn project.wsgi:application --bind 0.0.0.0:8000`
 	}
 
 	return fmt.Sprintf(`
-version: '3.9'
-
 services:
   app:
     image: %s
     container_name: django_app
     ports:
       - "8000:8000"
-%s    command: >
-      sh -c "python manage.py migrate &&
-             gunicorn project.wsgi:application --bind 0.0.0.0:8000"
-`, imageName, envSection)
+%s%s%s    command: >
+      %s
+`, imageName, envSection, appExtras(meta, opts), appHealthcheck("python", meta), command)
 }
 
 // ---------------------------------------------------
 // GO SERVICE
 // ---------------------------------------------------
 
-func composeGo(meta detect.ProjectMeta, imageName string) string {
+func composeGo(meta detect.ProjectMeta, imageName string, opts ComposeOptions) string {
 	return fmt.Sprintf(`
-version: '3.9'
-
 services:
   app:
     image: %s
     container_name: go_app
     ports:
       - "8080:8080"
-`, imageName)
+%s%s`, imageName, appEnvSection(meta), appExtras(meta, opts))
+}
+
+// ---------------------------------------------------
+// RUST / JAVA SERVICE (the image's CMD starts the app)
+// ---------------------------------------------------
+
+func composeBinary(container string, meta detect.ProjectMeta, imageName string, opts ComposeOptions) string {
+	return fmt.Sprintf(`
+services:
+  app:
+    image: %s
+    container_name: %s
+    ports:
+      - "%s:%s"
+%s%s`, imageName, container, meta.Port, meta.Port, appEnvSection(meta), appExtras(meta, opts))
 }
 
 // ---------------------------------------------------
 // ENV BLOCK + DATABASE SUPPORT
 // ---------------------------------------------------
 
+// appEnvSection renders env_file and environment for the app service.
+// The project's .env is not loaded as an env_file, which would hand every
+// secret to the container verbatim: its entries are listed under
+// environment instead, secrets as ${VAR} references compose resolves from
+// .env at runtime and private keys as *_FILE paths to their mounted
+// secret. environment wins over env_file, so project keys that
+// .env.docmake also sets are left out: a DATABASE_URL pointing at
+// localhost would otherwise replace the in-network one.
+func appEnvSection(meta detect.ProjectMeta) string {
+	var sb strings.Builder
+	if len(meta.Dependencies) > 0 {
+		sb.WriteString(fmt.Sprintf("    env_file:\n      - %s\n", LocalEnvFile))
+	}
+
+	if envBlock := buildEnvBlock(meta); envBlock != "" {
+		sb.WriteString(fmt.Sprintf("    environment:\n%s", envBlock))
+	}
+
+	return sb.String()
+}
+
 func buildEnvBlock(meta detect.ProjectMeta) string {
-	if len(meta.Env) == 0 {
-		if meta.Database.Type != "" && meta.Database.DefaultURI != "" {
-			envName := meta.Database.EnvVar
-			if envName == "" {
-				envName = "DATABASE_URL"
-			}
-			return fmt.Sprintf("      - %s=%s\n", envName, meta.Database.DefaultURI)
+	generated := generatedEnvKeys(meta)
+
+	var sb strings.Builder
+	for _, k := range detect.SortedKeys(meta.Env) {
+		if generated[k] {
+			continue
+		}
+		sb.WriteString(envLine(k, meta.Env[k], meta.Secrets[k]))
+	}
+
+	return sb.String()
+}
+
+// envLine renders one environment entry. Secrets are never inlined: file
+// secrets point at their /run/secrets mount, everything else is left for
+// compose to interpolate from the project's .env at runtime.
+func envLine(key, value string, kind detect.SecretKind) string {
+	switch {
+	case kind == detect.SecretPrivateKey:
+		return fmt.Sprintf("      - %s_FILE=/run/secrets/%s\n", key, composeSecretName(key))
+	case kind != "":
+		return fmt.Sprintf("      - %s=${%s}\n", key, key)
+	}
+
+	escaped := strings.ReplaceAll(value, `"`, `'`)
+	escaped = strings.ReplaceAll(escaped, "$", "$$")
+	return fmt.Sprintf("      - %s=%s\n", key, escaped)
+}
+
+// appExtras returns additional service-level keys for the app service.
+func appExtras(meta detect.ProjectMeta, opts ComposeOptions) string {
+	return serviceSecretsBlock(meta) + appVolumesBlock(meta) + hardeningBlock(opts.Hardening, meta) + appDependsOn(meta, opts)
+}
+
+// appVolumeName returns the named volume persisting an app directory
+func appVolumeName(dir string) string {
+	name := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			return r
 		}
+		return '_'
+	}, strings.ToLower(dir))
+	return "app_" + name
+}
+
+// RootDataDir holds database files found in the app root: a volume can't
+// be mounted over the root without hiding the code, so the files are
+// linked into this directory and it gets the volume instead
+const RootDataDir = ".data"
+
+// persistedDirs lists the app directories that get a named volume
+func persistedDirs(meta detect.ProjectMeta) []string {
+	dirs := meta.Persistence.Dirs
+	if len(meta.Persistence.RootFiles) > 0 {
+		dirs = append(append([]string{}, dirs...), RootDataDir)
+	}
+	return dirs
+}
+
+// appVolumesBlock mounts a named volume over every directory the app
+// writes state to (SQLite files, uploads), relative to WORKDIR /app
+func appVolumesBlock(meta detect.ProjectMeta) string {
+	dirs := persistedDirs(meta)
+	if len(dirs) == 0 {
 		return ""
 	}
 
 	var sb strings.Builder
-	for k, v := range meta.Env {
-		escaped := strings.ReplaceAll(v, `"`, `'`)
-		sb.WriteString(fmt.Sprintf("      - %s=%s\n", k, escaped))
+	sb.WriteString("    volumes:\n")
+	for _, dir := range dirs {
+		sb.WriteString(fmt.Sprintf("      - %s:/app/%s\n", appVolumeName(dir), dir))
 	}
+	return sb.String()
+}
 
-	if meta.Database.Type != "" && meta.Database.DefaultURI != "" {
-		envName := meta.Database.EnvVar
-		if envName == "" {
-			envName = "DATABASE_URL"
+// appDependsOn makes the app wait until its backing services are healthy
+// and the one-shot migrate service has completed. Seeding isn't awaited:
+// it only runs when asked for (see seedService).
+func appDependsOn(meta detect.ProjectMeta, opts ComposeOptions) string {
+	conditions := map[string]string{}
+	for _, dep := range meta.Dependencies {
+		if dependencyHealthcheck(dep.Type) != "" {
+			conditions[serviceName(dep.Type)] = "service_healthy"
+		} else {
+			conditions[serviceName(dep.Type)] = "service_started"
 		}
-		if _, exists := meta.Env[envName]; !exists {
-			sb.WriteString(fmt.Sprintf("      - %s=%s\n", envName, meta.Database.DefaultURI))
+		if dep.Type == "s3" {
+			conditions["minio-init"] = "service_completed_successfully"
 		}
 	}
-
-	return sb.String()
+	if hasMigrateService(meta) {
+		conditions["migrate"] = "service_completed_successfully"
+	}
+	return dependsOnBlock(conditions)
 }
 
 // ---------------------------------------------------
 // DATABASE SERVICES
 // ---------------------------------------------------
 
-func composeWithDB(appService string, db detect.DatabaseInfo) string {
-	if db.Type == "" {
-		return appService
+func composeWithDB(appService string, meta detect.ProjectMeta, opts ComposeOptions) string {
+	var services, volumes strings.Builder
+	for _, dir := range persistedDirs(meta) {
+		volumes.WriteString(fmt.Sprintf("  %s:\n", appVolumeName(dir)))
+	}
+
+	for _, dep := range meta.Dependencies {
+		service, volume := dependencyService(dep, meta, opts)
+		services.WriteString(service)
+		if volume != "" {
+			volumes.WriteString(fmt.Sprintf("  %s:\n", volume))
+		}
+	}
+
+	if volumes.Len() == 0 {
+		return appService + services.String()
+	}
+
+	return appService + services.String() + "\nvolumes:\n" + volumes.String()
+}
+
+// servicePorts renders the ports block of a backing service. Service ports
+// are only published when asked for; UI ports are meant for humans and are
+// always published. A "host:container" entry publishes on another host port.
+func servicePorts(opts ComposeOptions, ports []string, uiPorts ...string) string {
+	var published []string
+	if opts.PublishServicePorts {
+		published = append(published, ports...)
+	}
+	published = append(published, uiPorts...)
+
+	if len(published) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("    ports:\n")
+	for _, p := range published {
+		if !strings.Contains(p, ":") {
+			p += ":" + p
+		}
+		sb.WriteString(fmt.Sprintf("      - \"%s\"\n", p))
 	}
+	return sb.String()
+}
 
-	dbTemplate := ""
+// dependencyService returns the compose service for a backing dependency
+// and the named volume it persists to, if any.
+func dependencyService(dep detect.Dependency, meta detect.ProjectMeta, opts ComposeOptions) (string, string) {
+	ports := servicePorts(opts, []string{dep.Port})
+	health := dependencyHealthcheck(dep.Type)
 
-	switch db.Type {
+	switch dep.Type {
 	case "mongo":
-		dbTemplate = `
+		return fmt.Sprintf(`
   mongo:
-    image: mongo
-    ports:
-      - "27017:27017"
-    volumes:
+    image: mongo:7
+    env_file:
+      - %s
+%s%s    volumes:
       - mongo_data:/data/db
-`
+`, LocalEnvFile, ports, health), "mongo_data"
 	case "postgres":
-		dbTemplate = `
+		return fmt.Sprintf(`
   postgres:
-    image: postgres
-    environment:
-      POSTGRES_PASSWORD: password
-    ports:
-      - "5432:5432"
-    volumes:
+    image: postgres:16
+    env_file:
+      - %s
+%s%s    volumes:
       - pg_data:/var/lib/postgresql/data
-`
+`, LocalEnvFile, ports, health), "pg_data"
 	case "mysql":
-		dbTemplate = `
+		return fmt.Sprintf(`
   mysql:
     image: mysql:8
-    environment:
-      MYSQL_ROOT_PASSWORD: password
-    ports:
-      - "3306:3306"
-    volumes:
+    env_file:
+      - %s
+%s%s    volumes:
       - mysql_data:/var/lib/mysql
-`
+`, LocalEnvFile, ports, health), "mysql_data"
 	case "redis":
-		dbTemplate = `
+		return fmt.Sprintf(`
   redis:
-    image: redis
-    ports:
-      - "6379:6379"
-`
+    image: redis:7-alpine
+    env_file:
+      - %s
+    command: ["sh", "-c", "exec redis-server --requirepass \"$$REDIS_PASSWORD\""]
+%s%s`, LocalEnvFile, ports, health), ""
+	case "rabbitmq":
+		return fmt.Sprintf(`
+  rabbitmq:
+    image: rabbitmq:3.13-management-alpine
+    env_file:
+      - %s
+%s%s    volumes:
+      - rabbitmq_data:/var/lib/rabbitmq
+`, LocalEnvFile, servicePorts(opts, []string{dep.Port}, "15672"), health), "rabbitmq_data"
+	case "kafka":
+		// Single-node KRaft broker, no ZooKeeper needed
+		return fmt.Sprintf(`
+  kafka:
+    image: apache/kafka:3.7.0
+    environment:
+      KAFKA_NODE_ID: 1
+      KAFKA_PROCESS_ROLES: broker,controller
+      KAFKA_LISTENERS: PLAINTEXT://:9092,CONTROLLER://:9093
+      KAFKA_ADVERTISED_LISTENERS: PLAINTEXT://kafka:9092
+      KAFKA_CONTROLLER_LISTENER_NAMES: CONTROLLER
+      KAFKA_LISTENER_SECURITY_PROTOCOL_MAP: CONTROLLER:PLAINTEXT,PLAINTEXT:PLAINTEXT
+      KAFKA_CONTROLLER_QUORUM_VOTERS: 1@kafka:9093
+      KAFKA_OFFSETS_TOPIC_REPLICATION_FACTOR: 1
+      KAFKA_TRANSACTION_STATE_LOG_REPLICATION_FACTOR: 1
+      KAFKA_TRANSACTION_STATE_LOG_MIN_ISR: 1
+      KAFKA_GROUP_INITIAL_REBALANCE_DELAY_MS: 0
+      KAFKA_LOG_DIRS: /var/lib/kafka/data
+%s%s    volumes:
+      - kafka_data:/var/lib/kafka/data
+`, ports, health), "kafka_data"
+	case "nats":
+		return fmt.Sprintf(`
+  nats:
+    image: nats:2.10-alpine
+    command: ["-js", "-sd", "/data", "-m", "8222"]
+%s%s    volumes:
+      - nats_data:/data
+`, ports, health), "nats_data"
+	case "elasticsearch":
+		return fmt.Sprintf(`
+  elasticsearch:
+    image: docker.elastic.co/elasticsearch/elasticsearch:8.13.4
+    environment:
+      discovery.type: single-node
+      xpack.security.enabled: "false"
+      ES_JAVA_OPTS: -Xms512m -Xmx512m
+%s%s    volumes:
+      - es_data:/usr/share/elasticsearch/data
+`, ports, health), "es_data"
+	case "opensearch":
+		// Elasticsearch already holds host port 9200 when both are used
+		if detect.HasDependency(meta.Dependencies, "elasticsearch") {
+			ports = servicePorts(opts, []string{"9201:" + dep.Port})
+		}
+		return fmt.Sprintf(`
+  opensearch:
+    image: opensearchproject/opensearch:2.13.0
+    environment:
+      discovery.type: single-node
+      DISABLE_SECURITY_PLUGIN: "true"
+      OPENSEARCH_JAVA_OPTS: -Xms512m -Xmx512m
+%s%s    volumes:
+      - opensearch_data:/usr/share/opensearch/data
+`, ports, health), "opensearch_data"
+	case "s3":
+		// MinIO stand-in plus a one-shot container creating the bucket
+		return fmt.Sprintf(`
+  minio:
+    image: minio/minio:RELEASE.2024-06-13T22-53-53Z
+    command: ["server", "/data", "--console-address", ":9001"]
+    env_file:
+      - %s
+%s%s    volumes:
+      - minio_data:/data
+
+  minio-init:
+    image: minio/mc:RELEASE.2024-06-12T14-34-03Z
+    env_file:
+      - %s
+    depends_on:
+      minio:
+        condition: service_healthy
+    entrypoint: >
+      /bin/sh -c "
+      mc alias set local http://minio:9000 $$MINIO_ROOT_USER $$MINIO_ROOT_PASSWORD &&
+      mc mb --ignore-existing local/%s
+      "
+    restart: "no"
+`, LocalEnvFile, servicePorts(opts, []string{dep.Port}, "9001"), health, LocalEnvFile, s3Bucket(meta)), "minio_data"
+	case "smtp":
+		return fmt.Sprintf(`
+  mailpit:
+    image: axllent/mailpit:v1.18
+%s%s`, servicePorts(opts, []string{dep.Port}, "8025"), health), ""
 	}
 
-	volumeBlock := `
-volumes:
-  mongo_data:
-  pg_data:
-  mysql_data:
-`
+	return "", ""
+}
+
+// appHealthcheck probes the app's HTTP health endpoint with the runtime
+// already in the image (slim images ship neither curl nor wget). The Go
+// runtime image has no HTTP client at all, so Go apps get no probe.
+func appHealthcheck(runtime string, meta detect.ProjectMeta) string {
+	if meta.HealthPath == "" {
+		return ""
+	}
+
+	url := fmt.Sprintf("http://localhost:%s%s", meta.Port, meta.HealthPath)
+
+	var test []string
+	switch runtime {
+	case "node":
+		test = []string{"CMD", "node", "-e",
+			fmt.Sprintf("require('http').get('%s', r => process.exit(r.statusCode < 400 ? 0 : 1)).on('error', () => process.exit(1))", url)}
+	case "python":
+		test = []string{"CMD", "python", "-c",
+			fmt.Sprintf("import urllib.request; urllib.request.urlopen('%s', timeout=4)", url)}
+	default:
+		return ""
+	}
+
+	return fmt.Sprintf(`    healthcheck:
+      test: %s
+      interval: 10s
+      timeout: 5s
+      retries: 5
+      start_period: 15s
+`, flowSeq(test))
+}
+
+// dependencyHealthcheck returns a readiness probe for services other
+// containers wait on with condition: service_healthy
+func dependencyHealthcheck(depType string) string {
+	var test string
+	start := "10s"
 
-	return appService + dbTemplate + volumeBlock
+	switch depType {
+	case "postgres":
+		test = `["CMD-SHELL", "pg_isready -U $$POSTGRES_USER -d $$POSTGRES_DB"]`
+	case "mysql":
+		test = `["CMD-SHELL", "mysqladmin ping -h 127.0.0.1 -uroot -p$$MYSQL_ROOT_PASSWORD --silent"]`
+		start = "30s"
+	case "mongo":
+		test = `["CMD", "mongosh", "--quiet", "--eval", "db.adminCommand('ping')"]`
+	case "redis":
+		test = `["CMD-SHELL", "redis-cli -a \"$$REDIS_PASSWORD\" --no-auth-warning ping | grep -q PONG"]`
+	case "rabbitmq":
+		test = `["CMD", "rabbitmq-diagnostics", "-q", "ping"]`
+		start = "20s"
+	case "kafka":
+		test = `["CMD-SHELL", "/opt/kafka/bin/kafka-broker-api-versions.sh --bootstrap-server localhost:9092 > /dev/null"]`
+		start = "30s"
+	case "nats":
+		test = `["CMD", "wget", "-q", "--spider", "http://localhost:8222/healthz"]`
+	case "elasticsearch", "opensearch":
+		test = `["CMD-SHELL", "curl -fs http://localhost:9200/_cluster/health > /dev/null"]`
+		start = "60s"
+	case "s3":
+		test = `["CMD", "mc", "ready", "local"]`
+	case "smtp":
+		test = `["CMD", "/mailpit", "readyz"]`
+	default:
+		return ""
+	}
+
+	return fmt.Sprintf(`    healthcheck:
+      test: %s
+      interval: 5s
+      timeout: 5s
+      retries: 10
+      start_period: %s
+`, test, start)
+}
+
+// serviceName maps a dependency type to its compose service name
+func serviceName(depType string) string {
+	switch depType {
+	case "s3":
+		return "minio"
+	case "smtp":
+		return "mailpit"
+	}
+	return depType
+}
+
+// s3Bucket returns the bucket the app expects, falling back to "app"
+func s3Bucket(meta detect.ProjectMeta) string {
+	for _, k := range []string{"S3_BUCKET", "AWS_S3_BUCKET", "AWS_BUCKET_NAME", "BUCKET_NAME"} {
+		if v := meta.Env[k]; v != "" {
+			return v
+		}
+	}
+	return "app"
 }
 
 // ---------------------------------------------------
@@ -263,8 +598,6 @@ volumes:
 
 func composeMultiService(frontend, backend detect.ProjectMeta, fImage, bImage string) string {
 	return fmt.Sprintf(`
-version: '3.9'
-
 services:
 
   backend:
@@ -280,7 +613,7 @@ services:
       - backend
 
   mongo:
-    image: mongo
+    image: mongo:7
     ports:
       - "27017:27017"
 
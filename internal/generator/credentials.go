@@ -0,0 +1,217 @@
+package generator
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tejsvapandey1/docmake/internal/detect"
+)
+
+// LocalEnvFile holds generated credentials and the connection variables
+// derived from them. Both the backing services and the app load it, so
+// the two sides always agree.
+const LocalEnvFile = ".env.docmake"
+
+type credentialVar struct {
+	Name    string
+	Default string // empty means a random secret is generated
+}
+
+type credentialSpec struct {
+	Vars []credentialVar
+
+	// AppEnv builds the app's connection variables from the credentials
+	AppEnv func(c map[string]string) map[string]string
+}
+
+var credentialCatalog = map[string]credentialSpec{
+	"postgres": {
+		Vars: []credentialVar{{"POSTGRES_USER", "app"}, {"POSTGRES_PASSWORD", ""}, {"POSTGRES_DB", "app"}},
+		AppEnv: func(c map[string]string) map[string]string {
+			return map[string]string{
+				"DATABASE_URL": fmt.Sprintf("postgres://%s:%s@postgres:5432/%s?sslmode=disable", c["POSTGRES_USER"], c["POSTGRES_PASSWORD"], c["POSTGRES_DB"]),
+			}
+		},
+	},
+	"mysql": {
+		Vars: []credentialVar{{"MYSQL_USER", "app"}, {"MYSQL_PASSWORD", ""}, {"MYSQL_ROOT_PASSWORD", ""}, {"MYSQL_DATABASE", "app"}},
+		AppEnv: func(c map[string]string) map[string]string {
+			return map[string]string{
+				"MYSQL_URL": fmt.Sprintf("mysql://%s:%s@mysql:3306/%s", c["MYSQL_USER"], c["MYSQL_PASSWORD"], c["MYSQL_DATABASE"]),
+			}
+		},
+	},
+	"mongo": {
+		Vars: []credentialVar{{"MONGO_INITDB_ROOT_USERNAME", "app"}, {"MONGO_INITDB_ROOT_PASSWORD", ""}},
+		AppEnv: func(c map[string]string) map[string]string {
+			return map[string]string{
+				"MONGO_URI": fmt.Sprintf("mongodb://%s:%s@mongo:27017/?authSource=admin", c["MONGO_INITDB_ROOT_USERNAME"], c["MONGO_INITDB_ROOT_PASSWORD"]),
+			}
+		},
+	},
+	"redis": {
+		Vars: []credentialVar{{"REDIS_PASSWORD", ""}},
+		AppEnv: func(c map[string]string) map[string]string {
+			return map[string]string{
+				"REDIS_URL": fmt.Sprintf("redis://:%s@redis:6379", c["REDIS_PASSWORD"]),
+			}
+		},
+	},
+	"rabbitmq": {
+		Vars: []credentialVar{{"RABBITMQ_DEFAULT_USER", "app"}, {"RABBITMQ_DEFAULT_PASS", ""}},
+		AppEnv: func(c map[string]string) map[string]string {
+			auth := c["RABBITMQ_DEFAULT_USER"] + ":" + c["RABBITMQ_DEFAULT_PASS"]
+			return map[string]string{
+				"AMQP_URL": "amqp://" + auth + "@rabbitmq:5672/",
+			}
+		},
+	},
+	"s3": {
+		Vars: []credentialVar{{"MINIO_ROOT_USER", "docmake"}, {"MINIO_ROOT_PASSWORD", ""}},
+		AppEnv: func(c map[string]string) map[string]string {
+			return map[string]string{
+				"AWS_ACCESS_KEY_ID":     c["MINIO_ROOT_USER"],
+				"AWS_SECRET_ACCESS_KEY": c["MINIO_ROOT_PASSWORD"],
+			}
+		},
+	},
+}
+
+// celeryBrokerURLs build CELERY_BROKER_URL for the broker Celery uses
+var celeryBrokerURLs = map[string]func(c map[string]string) string{
+	"rabbitmq": func(c map[string]string) string {
+		return fmt.Sprintf("amqp://%s:%s@rabbitmq:5672//", c["RABBITMQ_DEFAULT_USER"], c["RABBITMQ_DEFAULT_PASS"])
+	},
+	"redis": func(c map[string]string) string {
+		return fmt.Sprintf("redis://:%s@redis:6379/0", c["REDIS_PASSWORD"])
+	},
+}
+
+// celeryBroker is the dependency the project's Celery processes consume
+// from, or ""
+func celeryBroker(meta detect.ProjectMeta) string {
+	for _, proc := range meta.Processes {
+		if strings.Contains(strings.Join(proc.Command, " "), "celery") {
+			return proc.Broker
+		}
+	}
+	return ""
+}
+
+// generatedEnvKeys lists the variables .env.docmake sets for the detected
+// dependencies: credentials and the in-network connection variables
+func generatedEnvKeys(meta detect.ProjectMeta) map[string]bool {
+	keys := map[string]bool{}
+	for _, dep := range meta.Dependencies {
+		if dep.EnvVar != "" && dep.DefaultURI != "" {
+			keys[dep.EnvVar] = true
+		}
+		for k := range dep.ExtraEnv {
+			keys[k] = true
+		}
+		if dep.Type == "s3" && s3Bucket(meta) == "app" {
+			keys["S3_BUCKET"] = true
+		}
+		if spec, ok := credentialCatalog[dep.Type]; ok {
+			for _, v := range spec.Vars {
+				keys[v.Name] = true
+			}
+			for k := range spec.AppEnv(map[string]string{}) {
+				keys[k] = true
+			}
+			if _, ok := celeryBrokerURLs[dep.Type]; ok && dep.Type == celeryBroker(meta) {
+				keys["CELERY_BROKER_URL"] = true
+			}
+		}
+	}
+	return keys
+}
+
+// ---------------------------------------------------
+// .env.docmake
+// ---------------------------------------------------
+
+// writeLocalEnv (re)writes .env.docmake. Credentials already present in the
+// file are kept so re-running docmake doesn't lock the app out of existing
+// database volumes.
+func writeLocalEnv(path string, meta detect.ProjectMeta) error {
+	if len(meta.Dependencies) == 0 {
+		return nil
+	}
+
+	filePath := filepath.Join(path, LocalEnvFile)
+	existing, _ := detect.ParseEnvFile(filePath)
+
+	var sb strings.Builder
+	sb.WriteString("# Generated by docmake: local credentials for docker compose.\n")
+	sb.WriteString("# Keep this file out of version control.\n")
+
+	for _, dep := range meta.Dependencies {
+		sb.WriteString(fmt.Sprintf("\n# %s\n", dep.Type))
+
+		appEnv := map[string]string{}
+		if dep.EnvVar != "" && dep.DefaultURI != "" {
+			appEnv[dep.EnvVar] = dep.DefaultURI
+		}
+		for k, v := range dep.ExtraEnv {
+			appEnv[k] = v
+		}
+		if dep.Type == "s3" && s3Bucket(meta) == "app" {
+			appEnv["S3_BUCKET"] = "app"
+		}
+
+		if spec, ok := credentialCatalog[dep.Type]; ok {
+			creds := map[string]string{}
+			for _, v := range spec.Vars {
+				value := existing[v.Name]
+				if value == "" {
+					value = v.Default
+				}
+				if value == "" {
+					secret, err := randomSecret(24)
+					if err != nil {
+						return err
+					}
+					value = secret
+				}
+				creds[v.Name] = value
+				sb.WriteString(fmt.Sprintf("%s=%s\n", v.Name, value))
+			}
+
+			for k, v := range spec.AppEnv(creds) {
+				appEnv[k] = v
+			}
+			if broker, ok := celeryBrokerURLs[dep.Type]; ok && dep.Type == celeryBroker(meta) {
+				appEnv["CELERY_BROKER_URL"] = broker(creds)
+			}
+		}
+
+		for _, k := range detect.SortedKeys(appEnv) {
+			sb.WriteString(fmt.Sprintf("%s=%s\n", k, appEnv[k]))
+		}
+	}
+
+	return os.WriteFile(filePath, []byte(sb.String()), 0600)
+}
+
+const secretAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// randomSecret returns n URL-safe characters, so it can be embedded in
+// connection strings without escaping
+func randomSecret(n int) (string, error) {
+	max := big.NewInt(int64(len(secretAlphabet)))
+
+	buf := make([]byte, n)
+	for i := range buf {
+		idx, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		buf[i] = secretAlphabet[idx.Int64()]
+	}
+	return string(buf), nil
+}
@@ -4,11 +4,37 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/tejsvapandey1/docmake/internal/detect"
 )
 
-func GenerateDockerfile(path string, stack *detect.TechStack, meta detect.ProjectMeta) error {
+// syntaxDirective pins the Dockerfile frontend so RUN --mount cache mounts
+// work on any BuildKit version
+const syntaxDirective = "# syntax=docker/dockerfile:1\n"
+
+// BuildKit cache mounts for package managers. They persist between builds
+// on the same builder without ending up in image layers.
+const (
+	goModCache   = "--mount=type=cache,target=/go/pkg/mod"
+	goBuildCache = "--mount=type=cache,target=/root/.cache/go-build"
+	pipCache     = "--mount=type=cache,target=/root/.cache/pip"
+	npmCache     = "--mount=type=cache,target=/root/.npm"
+	cargoCache   = "--mount=type=cache,target=/usr/local/cargo/registry"
+	cargoTarget  = "--mount=type=cache,target=/app/target"
+	mavenCache   = "--mount=type=cache,target=/root/.m2"
+)
+
+// Unprivileged users the runtime stages switch to
+const (
+	appUID      = "10001"
+	nonrootUID  = "65532" // distroless "nonroot"
+	nodeUser    = "node"  // ships with the node images
+	nodeUID     = "1000"
+	appUserName = "app"
+)
+
+func GenerateDockerfile(path string, stack *detect.TechStack, meta detect.ProjectMeta, h Hardening) error {
 	filePath := filepath.Join(path, "Dockerfile")
 
 	var content string
@@ -16,112 +42,453 @@ func GenerateDockerfile(path string, stack *detect.TechStack, meta detect.Projec
 	switch stack.Primary {
 
 	case "go":
-		content = dockerfileGo(meta)
+		content = dockerfileGo(meta, h)
 
 	case "python":
-		content = dockerfilePython(meta)
+		content = dockerfilePython(meta, h)
+
+	case "rust":
+		content = dockerfileRust(meta, h)
+
+	case "java-maven":
+		content = dockerfileMaven(meta, h)
 
 	case "node":
 		switch meta.Framework {
 		case "nextjs":
-			content = dockerfileNext()
+			content = dockerfileNext(h)
 		case "react":
-			content = dockerfileReact()
+			content = dockerfileReact(h)
 		case "express", "nestjs", "":
 			// Default node backend
-			content = dockerfileNode(meta)
+			content = dockerfileNode(meta, h)
 		}
 
 	default:
 		return fmt.Errorf("unsupported tech stack: %s", stack.Primary)
 	}
 
+	// The syntax directive is only honoured on the very first line
+	content = syntaxDirective + strings.TrimLeft(content, "\n") + labelStep(path, stack, meta)
+
 	return os.WriteFile(filePath, []byte(content), 0644)
 }
 
-func dockerfileGo(meta detect.ProjectMeta) string {
-	return `
-FROM golang:1.22 AS builder
+func dockerfileGo(meta detect.ProjectMeta, h Hardening) string {
+	// cgo SQLite drivers link against glibc: build on the same Debian
+	// release as the runtime image
+	cgo := "0"
+	if meta.Persistence.CGO {
+		cgo = "1"
+	}
+
+	// Pure Go cross-compiles: the builder runs natively on the build host
+	// and targets each platform of a multi-arch build. cgo needs the
+	// target's C toolchain, so those builds run on the target platform.
+	builder := "FROM --platform=$BUILDPLATFORM golang:1.22-bookworm AS builder\nARG TARGETOS TARGETARCH"
+	goEnv := "GOOS=$TARGETOS GOARCH=$TARGETARCH "
+	if meta.Persistence.CGO {
+		builder, goEnv = "FROM golang:1.22-bookworm AS builder", ""
+	}
+
+	// Distroless has no shell: keep Debian when a process runs "sh -c"
+	runtime, uid := "debian:bookworm-slim", appUID
+	if h.On(RuleSlim) && !needsShell(meta) {
+		runtime = "gcr.io/distroless/static-debian12"
+		if meta.Persistence.CGO {
+			runtime = "gcr.io/distroless/base-debian12"
+		}
+		uid = nonrootUID
+		if h.On(RuleUser) {
+			runtime += ":nonroot"
+		}
+	}
+
+	chown, user := "", ""
+	if h.On(RuleUser) {
+		chown = fmt.Sprintf("--chown=%s:%s ", uid, uid)
+		user = fmt.Sprintf("USER %s:%s\n", uid, uid)
+	}
+
+	return fmt.Sprintf(`
+%s
 WORKDIR /app
 
+# Modules first: this layer is reused until go.mod/go.sum change
+COPY go.mod go.sum* ./
+RUN %s go mod download
+
 COPY . .
-RUN go build -o app .
+RUN %s %s \
+    CGO_ENABLED=%s %sgo build -o /out/app .
+%s
+FROM %s
+WORKDIR /app
+
+COPY --from=builder %s/out/ /app/
+%s
+EXPOSE 8080
+CMD ["./app"]
+`, builder, goModCache, goModCache, goBuildCache, cgo, goEnv, builderDataDirs(meta), runtime, chown, user)
+}
+
+func dockerfileRust(meta detect.ProjectMeta, h Hardening) string {
+	// Same runtime choice as Go; cargo's glibc binaries need libgcc from
+	// the cc variant of distroless
+	runtime, uid := "debian:bookworm-slim", appUID
+	if h.On(RuleSlim) && !needsShell(meta) {
+		runtime, uid = "gcr.io/distroless/cc-debian12", nonrootUID
+		if h.On(RuleUser) {
+			runtime += ":nonroot"
+		}
+	}
+
+	chown, user := "", ""
+	if h.On(RuleUser) {
+		chown = fmt.Sprintf("--chown=%s:%s ", uid, uid)
+		user = fmt.Sprintf("USER %s:%s\n", uid, uid)
+	}
 
-FROM debian:bookworm-slim
+	// The builder runs natively on the build host and cross-compiles for
+	// each platform of a multi-arch build with Debian's cross toolchains.
+	// Registry and target caches replace a manifest-first layer: cargo
+	// can't fetch without the sources and rebuilds incrementally from
+	// the cached target directory.
+	return fmt.Sprintf(`
+FROM --platform=$BUILDPLATFORM rust:1-bookworm AS builder
+ARG BUILDARCH TARGETARCH
 WORKDIR /app
 
-COPY --from=builder /app/app .
+RUN case "$TARGETARCH" in \
+      amd64) target=x86_64-unknown-linux-gnu gcc=gcc-x86-64-linux-gnu ;; \
+      arm64) target=aarch64-unknown-linux-gnu gcc=gcc-aarch64-linux-gnu ;; \
+      *) echo "no Rust target for $TARGETARCH" >&2 && exit 1 ;; \
+    esac \
+    && if [ "$TARGETARCH" != "$BUILDARCH" ]; then \
+        apt-get update \
+        && apt-get install -y --no-install-recommends $gcc libc6-dev-$TARGETARCH-cross \
+        && rm -rf /var/lib/apt/lists/*; \
+    fi \
+    && rustup target add $target \
+    && echo $target > /rust-target
+ENV CARGO_TARGET_X86_64_UNKNOWN_LINUX_GNU_LINKER=x86_64-linux-gnu-gcc \
+    CARGO_TARGET_AARCH64_UNKNOWN_LINUX_GNU_LINKER=aarch64-linux-gnu-gcc
 
-EXPOSE 8080
+COPY . .
+RUN %s %s \
+    cargo build --release --target "$(cat /rust-target)" \
+    && mkdir -p /out \
+    && cp "target/$(cat /rust-target)/release/%s" /out/app
+%s
+FROM %s
+WORKDIR /app
+
+COPY --from=builder %s/out/ /app/
+%s
+EXPOSE %s
 CMD ["./app"]
-`
+`, cargoCache, cargoTarget, meta.EntryFile, builderDataDirs(meta), runtime, chown, user, meta.Port)
 }
 
-func dockerfilePython(meta detect.ProjectMeta) string {
+func dockerfileMaven(meta detect.ProjectMeta, h Hardening) string {
+	// The jar is the same on every platform, so the builder always runs
+	// natively on the build host
+	runtime, uid := "eclipse-temurin:21-jre", appUID
+	if h.On(RuleSlim) && !needsShell(meta) {
+		runtime, uid = "gcr.io/distroless/java21-debian12", nonrootUID
+		if h.On(RuleUser) {
+			runtime += ":nonroot"
+		}
+	}
+
+	chown, user := "", ""
+	if h.On(RuleUser) {
+		chown = fmt.Sprintf("--chown=%s:%s ", uid, uid)
+		user = fmt.Sprintf("USER %s:%s\n", uid, uid)
+	}
+
+	// Both runtimes ship their own ENTRYPOINT; CMD alone has to work for
+	// the app and for processes sharing the image
 	return fmt.Sprintf(`
-FROM python:3.11-slim
+FROM --platform=$BUILDPLATFORM maven:3.9-eclipse-temurin-21 AS builder
+WORKDIR /app
+
+# Dependencies first: this layer is reused until pom.xml changes
+COPY pom.xml ./
+RUN %s mvn -B -q dependency:go-offline
 
+COPY . .
+RUN %s mvn -B -q package -DskipTests \
+    && mkdir -p /out \
+    && find target -maxdepth 1 -name '*.jar' ! -name '*-sources.jar' ! -name '*-javadoc.jar' -exec cp {} /out/app.jar \;
+%s
+FROM %s
 WORKDIR /app
 
+COPY --from=builder %s/out/ /app/
+%s
+EXPOSE %s
+ENTRYPOINT []
+CMD ["java", "-jar", "app.jar"]
+`, mavenCache, mavenCache, builderDataDirs(meta), runtime, chown, user, meta.Port)
+}
+
+// builderDataDirs creates the persisted directories under /out in the
+// builder so they can be copied with the right owner into a shell-less
+// runtime
+func builderDataDirs(meta detect.ProjectMeta) string {
+	persisted := persistedDirs(meta)
+	if len(persisted) == 0 {
+		return ""
+	}
+
+	dirs := "RUN mkdir -p /out/" + strings.Join(persisted, " /out/")
+	for _, f := range meta.Persistence.RootFiles {
+		dirs += fmt.Sprintf(" \\\n    && ln -s %s/%s /out/%s", RootDataDir, f, f)
+	}
+	return dirs + "\n"
+}
+
+// needsShell reports whether any process sharing the image runs "sh -c"
+func needsShell(meta detect.ProjectMeta) bool {
+	for _, proc := range meta.Processes {
+		if len(proc.Command) > 0 && proc.Command[0] == "sh" {
+			return true
+		}
+	}
+	return false
+}
+
+// dataDirsStep pre-creates persisted directories so named volumes mounted
+// over them start out with the image's ownership. Database files in the
+// app root become links into RootDataDir; a file shipped with the repo
+// moves there and seeds the volume.
+func dataDirsStep(meta detect.ProjectMeta, owner string) string {
+	persisted := persistedDirs(meta)
+	if len(persisted) == 0 {
+		return ""
+	}
+	dirs := strings.Join(persisted, " ")
+
+	var sb strings.Builder
+	sb.WriteString("RUN mkdir -p " + dirs)
+	for _, f := range meta.Persistence.RootFiles {
+		sb.WriteString(fmt.Sprintf(" \\\n    && if [ -e %s ]; then mv %s %s/; fi && ln -s %s/%s %s", f, f, RootDataDir, RootDataDir, f, f))
+	}
+	if owner != "" {
+		sb.WriteString(fmt.Sprintf(" \\\n    && chown -R %s %s", owner, dirs))
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// aptInstallStep installs Debian packages without recommends and drops the
+// apt lists in the same layer
+func aptInstallStep(pkgs []string) string {
+	if len(pkgs) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(`
+RUN apt-get update \
+    && apt-get install -y --no-install-recommends %s \
+    && rm -rf /var/lib/apt/lists/*
+`, strings.Join(pkgs, " "))
+}
+
+// userStep switches to the runtime user
+func userStep(h Hardening, user string) string {
+	if !h.On(RuleUser) {
+		return ""
+	}
+	return "USER " + user + "\n"
+}
+
+func dockerfilePython(meta detect.ProjectMeta, h Hardening) string {
+	pkgs := meta.SystemPackages
+
+	// No .pyc writes, so the root filesystem can stay read-only
+	env := ""
+	if h.On(RuleReadOnly) {
+		env = "ENV PYTHONDONTWRITEBYTECODE=1 \\\n    PYTHONUNBUFFERED=1\n"
+	}
+
+	create, owner := "", ""
+	if h.On(RuleUser) {
+		create = fmt.Sprintf("RUN useradd --system --uid %s --no-create-home %s\n", appUID, appUserName)
+		owner = appUserName
+	}
+	runtime := create + dataDirsStep(meta, owner) + userStep(h, appUserName)
+
+	if len(pkgs.Build) == 0 {
+		return fmt.Sprintf(`
+FROM python:3.11-slim
+%s
+WORKDIR /app
+%s
 COPY requirements.txt .
 
-RUN pip install --no-cache-dir -r requirements.txt
+RUN %s pip install -r requirements.txt
 
 COPY . .
+%s
+EXPOSE %s
+
+CMD ["python", "%s"]
+`, env, aptInstallStep(pkgs.Runtime), pipCache, runtime, meta.Port, meta.EntryFile)
+	}
+
+	// Native extensions compile in the builder; only their shared
+	// libraries reach the runtime image
+	return fmt.Sprintf(`
+FROM python:3.11-slim AS builder
+
+WORKDIR /app
+%s
+COPY requirements.txt .
+
+RUN %s pip install --prefix=/install -r requirements.txt
+
+FROM python:3.11-slim
+%s
+WORKDIR /app
+%s
+COPY --from=builder /install /usr/local
 
+COPY . .
+%s
 EXPOSE %s
 
 CMD ["python", "%s"]
-`, meta.Port, meta.EntryFile)
+`, aptInstallStep(pkgs.Build), pipCache, env, aptInstallStep(pkgs.Runtime), runtime, meta.Port, meta.EntryFile)
 }
 
-func dockerfileNode(meta detect.ProjectMeta) string {
-	return fmt.Sprintf(`
-FROM node:20
+func dockerfileNode(meta detect.ProjectMeta, h Hardening) string {
+	pkgs := meta.SystemPackages
+
+	// npm keeps its cache under $HOME, which is read-only at runtime
+	env := ""
+	if h.On(RuleReadOnly) {
+		env = "ENV NPM_CONFIG_CACHE=/tmp/.npm\n"
+	}
+
+	owner := ""
+	if h.On(RuleUser) {
+		owner = nodeUser
+	}
 
+	if !h.On(RuleSlim) && len(pkgs.Build) == 0 && len(pkgs.Runtime) == 0 {
+		return fmt.Sprintf(`
+FROM node:20
+%s
 WORKDIR /app
 
 COPY package*.json ./
-RUN npm install
+RUN %s npm install
 
 COPY . .
+%s%s
+EXPOSE %s
+
+CMD ["node", "%s"]
+`, env, npmCache, dataDirsStep(meta, owner), userStep(h, nodeUser), meta.Port, meta.EntryFile)
+	}
+
+	// Dependencies (and node-gyp addons) build in the full image; the
+	// runtime only gets node_modules and the libraries they link against
+	runtime := "node:20"
+	if h.On(RuleSlim) {
+		runtime = "node:20-slim"
+	}
+
+	return fmt.Sprintf(`
+FROM node:20 AS builder
+
+WORKDIR /app
+%s
+COPY package*.json ./
+RUN %s npm install
 
+FROM %s
+%s
+WORKDIR /app
+%s
+COPY --from=builder /app/node_modules ./node_modules
+
+COPY . .
+%s%s
 EXPOSE %s
 
 CMD ["node", "%s"]
-`, meta.Port, meta.EntryFile)
+`, aptInstallStep(pkgs.Build), npmCache, runtime, env, aptInstallStep(pkgs.Runtime), dataDirsStep(meta, owner), userStep(h, nodeUser), meta.Port, meta.EntryFile)
 }
 
-func dockerfileReact() string {
-	return `
-FROM node:20 AS builder
+func dockerfileReact(h Hardening) string {
+	// The unprivileged nginx image runs as uid 101 and listens on 8080
+	runtime, port := "nginx:alpine", "80"
+	if h.On(RuleUser) {
+		runtime, port = "nginxinc/nginx-unprivileged:alpine", "8080"
+	}
+
+	// The static bundle is the same on every platform, so the builder
+	// always runs natively on the build host
+	return fmt.Sprintf(`
+FROM --platform=$BUILDPLATFORM node:20 AS builder
 WORKDIR /app
 
 COPY package*.json ./
-RUN npm install
+RUN %s npm install
 
 COPY . .
 
 RUN npm run build
 
-FROM nginx:alpine
+FROM %s
 COPY --from=builder /app/build /usr/share/nginx/html
-`
+
+EXPOSE %s
+`, npmCache, runtime, port)
 }
 
-func dockerfileNext() string {
-	return `
-FROM node:20 AS deps
+func dockerfileNext(h Hardening) string {
+	runtime := "node:20"
+	if h.On(RuleSlim) {
+		runtime = "node:20-slim"
+	}
+
+	// npm keeps its cache and logs under $HOME, which is read-only at runtime
+	env := ""
+	if h.On(RuleReadOnly) {
+		env = "ENV NPM_CONFIG_CACHE=/tmp/.npm\n"
+	}
+
+	// next start writes the image and fetch caches (and ISR pages) under
+	// .next; the rest of the app stays root-owned
+	chown := ""
+	if h.On(RuleUser) {
+		chown = fmt.Sprintf("--chown=%s:%s ", nodeUser, nodeUser)
+	}
+
+	return fmt.Sprintf(`
+FROM node:20 AS builder
 WORKDIR /app
 
 COPY package*.json ./
-RUN npm install
+RUN %s npm install
 
 COPY . .
 
-RUN npm run build
+RUN npm run build \
+    && mkdir -p .next/cache \
+    && mv .next /next
+
+FROM %s
+%s
+WORKDIR /app
 
+COPY --from=builder /app ./
+COPY --from=builder %s/next ./.next
+%s
 EXPOSE 3000
 CMD ["npm", "start"]
-`
+`, npmCache, runtime, env, chown, userStep(h, nodeUser))
 }
@@ -0,0 +1,168 @@
+package generator
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tejsvapandey1/docmake/internal/detect"
+)
+
+const dockerignoreMarker = "# ---- added by docmake ----"
+
+// Patterns every build context should drop, whatever the stack
+var baseIgnorePatterns = []string{
+	".git",
+	".gitignore",
+	".dockerignore",
+	"docker-compose*.yml",
+	".docmake",
+	"**/.env",
+	"**/.env.*",
+	"!**/.env.example",
+	"**/*.pem",
+	"**/*.key",
+	"**/*.p12",
+	"**/*.pfx",
+	"**/id_rsa*",
+	"**/id_ed25519*",
+	"**/.aws",
+	"**/.ssh",
+	"**/.DS_Store",
+	".idea",
+	".vscode",
+	"**/coverage",
+	"**/testdata",
+}
+
+var stackIgnorePatterns = map[string][]string{
+	"node": {
+		"**/node_modules",
+		"**/npm-debug.log*",
+		"**/yarn-error.log*",
+		".next",
+		"**/__tests__",
+		".npmrc",
+	},
+	"python": {
+		"**/__pycache__",
+		"**/*.py[cod]",
+		".venv",
+		"venv",
+		".pytest_cache",
+		".mypy_cache",
+		".tox",
+		"**/*.egg-info",
+		"tests",
+	},
+	"rust": {
+		"target",
+	},
+	"java-maven": {
+		"target",
+	},
+	"go": {
+		"bin",
+		"**/*_test.go",
+		"**/*.test",
+		"**/*.out",
+	},
+}
+
+// GenerateDockerignore writes a stack-aware .dockerignore. An existing file
+// is kept as-is and only missing patterns are appended, together with
+// hints taken from .gitignore.
+func GenerateDockerignore(path string, stack *detect.TechStack) error {
+	filePath := filepath.Join(path, ".dockerignore")
+
+	existing := readIgnoreLines(filePath)
+	seen := make(map[string]bool)
+	for _, l := range existing {
+		seen[l] = true
+	}
+
+	var wanted []string
+	wanted = append(wanted, baseIgnorePatterns...)
+	wanted = append(wanted, stackIgnorePatterns[stack.Primary]...)
+	for _, l := range readIgnoreLines(filepath.Join(path, ".gitignore")) {
+		if p := gitignoreToDockerignore(l); p != "" {
+			wanted = append(wanted, p)
+		}
+	}
+
+	var added []string
+	for _, p := range wanted {
+		if seen[p] {
+			continue
+		}
+		seen[p] = true
+		added = append(added, p)
+	}
+
+	if len(added) == 0 {
+		return nil
+	}
+
+	var sb strings.Builder
+	if len(existing) > 0 {
+		raw, _ := os.ReadFile(filePath)
+		sb.Write(raw)
+		if !strings.HasSuffix(string(raw), "\n") {
+			sb.WriteString("\n")
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString(dockerignoreMarker + "\n")
+	for _, p := range added {
+		sb.WriteString(p + "\n")
+	}
+
+	return os.WriteFile(filePath, []byte(sb.String()), 0644)
+}
+
+// readIgnoreLines returns the non-empty, non-comment lines of an ignore file
+func readIgnoreLines(path string) []string {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// gitignoreToDockerignore converts a .gitignore pattern to the root-relative
+// form .dockerignore expects. Negations are dropped: re-including files the
+// developer ignored is never what we want in an image.
+func gitignoreToDockerignore(pattern string) string {
+	if strings.HasPrefix(pattern, "!") {
+		return ""
+	}
+
+	p := strings.TrimSuffix(pattern, "/")
+	if p == "" {
+		return ""
+	}
+
+	// Anchored patterns are already relative to the root
+	if strings.HasPrefix(p, "/") {
+		return strings.TrimPrefix(p, "/")
+	}
+
+	// Unanchored patterns without a slash match at any depth in git
+	if !strings.Contains(p, "/") && !strings.HasPrefix(p, "**") {
+		return "**/" + p
+	}
+
+	return p
+}
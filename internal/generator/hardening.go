@@ -0,0 +1,140 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/tejsvapandey1/docmake/internal/detect"
+)
+
+// Hardening rules applied to generated Dockerfiles and compose services.
+// Every rule is on unless listed in --no-harden.
+const (
+	RuleUser            = "user"              // run as an unprivileged user
+	RuleSlim            = "slim"              // slim/distroless runtime stages
+	RuleDigest          = "digest"            // pin base images by digest
+	RuleNoNewPrivileges = "no-new-privileges" // compose security_opt
+	RuleCapDrop         = "cap-drop"          // compose cap_drop: [ALL]
+	RuleReadOnly        = "read-only"         // compose read_only + tmpfs /tmp
+)
+
+var hardeningRules = []string{RuleUser, RuleSlim, RuleDigest, RuleNoNewPrivileges, RuleCapDrop, RuleReadOnly}
+
+// Hardening records which rules are switched off. The zero value enables
+// every rule.
+type Hardening struct {
+	Disabled map[string]bool
+}
+
+// NewHardening builds a Hardening from the rule names the user opted out of
+func NewHardening(optOut []string) (Hardening, error) {
+	h := Hardening{Disabled: map[string]bool{}}
+	for _, rule := range optOut {
+		rule = strings.TrimSpace(rule)
+		if !isHardeningRule(rule) {
+			return h, fmt.Errorf("unknown hardening rule %q (valid: %s)", rule, strings.Join(hardeningRules, ", "))
+		}
+		h.Disabled[rule] = true
+	}
+	return h, nil
+}
+
+// On reports whether rule is enabled
+func (h Hardening) On(rule string) bool {
+	return !h.Disabled[rule]
+}
+
+func isHardeningRule(rule string) bool {
+	for _, r := range hardeningRules {
+		if r == rule {
+			return true
+		}
+	}
+	return false
+}
+
+// ---------------------------------------------------
+// COMPOSE
+// ---------------------------------------------------
+
+// hardeningBlock renders the service-level security settings for
+// containers running the app image
+func hardeningBlock(h Hardening, meta detect.ProjectMeta) string {
+	var sb strings.Builder
+
+	if h.On(RuleNoNewPrivileges) {
+		sb.WriteString("    security_opt:\n      - no-new-privileges:true\n")
+	}
+	if h.On(RuleCapDrop) {
+		sb.WriteString("    cap_drop:\n      - ALL\n")
+	}
+	if h.On(RuleReadOnly) {
+		// Scratch space for temp files, sockets and caches
+		sb.WriteString("    read_only: true\n    tmpfs:\n      - /tmp\n")
+		for _, dir := range scratchDirs(meta, h) {
+			sb.WriteString("      - " + dir + "\n")
+		}
+	}
+
+	return sb.String()
+}
+
+// scratchDirs are app paths a framework writes to at runtime, with the
+// tmpfs options that keep them writable for the runtime user
+func scratchDirs(meta detect.ProjectMeta, h Hardening) []string {
+	if meta.Framework != "nextjs" {
+		return nil
+	}
+	dir := "/app/.next/cache"
+	if h.On(RuleUser) {
+		dir += fmt.Sprintf(":uid=%s,gid=%s", nodeUID, nodeUID)
+	}
+	return []string{dir}
+}
+
+// ---------------------------------------------------
+// BASE IMAGE DIGESTS
+// ---------------------------------------------------
+
+// Flags such as --platform=$BUILDPLATFORM stay in the prefix
+var fromLine = regexp.MustCompile(`(?m)^(FROM\s+(?:--\S+\s+)*)(\S+)(.*)$`)
+
+// PinBaseImages rewrites every FROM in the generated Dockerfile to
+// image:tag@sha256:... using resolve. Images that can't be resolved keep
+// their tag and are returned so the caller can warn about them.
+func PinBaseImages(path string, resolve func(ref string) (string, error)) ([]string, error) {
+	filePath := filepath.Join(path, "Dockerfile")
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	stages := map[string]bool{}
+	var unpinned []string
+
+	content := fromLine.ReplaceAllStringFunc(string(data), func(line string) string {
+		m := fromLine.FindStringSubmatch(line)
+		image, rest := m[2], m[3]
+
+		// Remember stage names; "FROM builder" isn't an image
+		if fields := strings.Fields(rest); len(fields) == 2 && strings.EqualFold(fields[0], "AS") {
+			stages[fields[1]] = true
+		}
+		if stages[image] || strings.Contains(image, "@") {
+			return line
+		}
+
+		digest, err := resolve(image)
+		if err != nil {
+			unpinned = append(unpinned, fmt.Sprintf("%s (%v)", image, err))
+			return line
+		}
+		return m[1] + image + "@" + digest + rest
+	})
+
+	return unpinned, os.WriteFile(filePath, []byte(content), 0644)
+}
@@ -0,0 +1,83 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// StateDir holds what docmake records about a project's builds
+const StateDir = ".docmake"
+
+// GeneratedFiles lists the paths docmake writes into a project, so they
+// don't count as local changes to it
+var GeneratedFiles = []string{"Dockerfile", ".dockerignore", "docker-compose.yml", LocalEnvFile, SecretsDir, StateDir}
+
+// ---------------------------------------------------
+// PUSHED IMAGE RECORD
+// ---------------------------------------------------
+
+// ImageRecord describes the image pushed for a commit
+type ImageRecord struct {
+	Image     string    `json:"image"` // repository without tag
+	Tags      []string  `json:"tags"`
+	Digest    string    `json:"digest"`
+	Pinned    string    `json:"pinned"` // reference compose runs
+	Commit    string    `json:"commit,omitempty"`
+	Dirty     bool      `json:"dirty,omitempty"`
+	Platforms []string  `json:"platforms,omitempty"` // set for image indexes
+	PushedAt  time.Time `json:"pushed_at"`
+}
+
+// WriteImageRecord saves rec as .docmake/image.json and returns its path
+func WriteImageRecord(path string, rec ImageRecord) (string, error) {
+	dir := filepath.Join(path, StateDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	filePath := filepath.Join(dir, "image.json")
+	return filePath, os.WriteFile(filePath, append(data, '\n'), 0644)
+}
+
+// ReadImageRecord loads the .docmake/image.json written for path
+func ReadImageRecord(path string) (ImageRecord, error) {
+	var rec ImageRecord
+	data, err := os.ReadFile(filepath.Join(path, StateDir, "image.json"))
+	if err != nil {
+		return rec, err
+	}
+	return rec, json.Unmarshal(data, &rec)
+}
+
+// ---------------------------------------------------
+// COMPOSE DIGEST PINNING
+// ---------------------------------------------------
+
+// PinComposeImage points every service running image at pinned
+// (image:tag@sha256:...) so compose runs exactly what was pushed, not
+// whatever the mutable tag points at later
+func PinComposeImage(path, image, pinned string) error {
+	filePath := filepath.Join(path, "docker-compose.yml")
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	line := regexp.MustCompile(`(?m)^(\s*image:\s*)` + regexp.QuoteMeta(image) + `\s*$`)
+	if !line.Match(data) {
+		return fmt.Errorf("no service uses image %s", image)
+	}
+
+	content := line.ReplaceAllString(string(data), "${1}"+pinned)
+	return os.WriteFile(filePath, []byte(content), 0644)
+}
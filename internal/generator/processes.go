@@ -0,0 +1,37 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tejsvapandey1/docmake/internal/detect"
+)
+
+// ---------------------------------------------------
+// WORKER + SCHEDULER SERVICES
+// ---------------------------------------------------
+
+// composeProcesses emits one long-running service per detected worker or
+// scheduler. They run the app image with the app's environment, volumes
+// and readiness conditions, so they start after the broker is healthy and
+// migrations have completed.
+func composeProcesses(meta detect.ProjectMeta, imageName string, opts ComposeOptions) string {
+	var sb strings.Builder
+
+	for _, proc := range meta.Processes {
+		cmd := make([]string, len(proc.Command))
+		for i, arg := range proc.Command {
+			// Leave $VARS for the container's shell, not compose
+			cmd[i] = strings.ReplaceAll(arg, "$", "$$")
+		}
+
+		sb.WriteString(fmt.Sprintf(`
+  %s:
+    image: %s
+%s%s    command: %s
+    restart: unless-stopped
+`, proc.Name, imageName, appEnvSection(meta), appExtras(meta, opts), flowSeq(cmd)))
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,121 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tejsvapandey1/docmake/internal/detect"
+)
+
+// SecretsDir holds file-backed compose secrets, relative to the repo root
+const SecretsDir = ".docmake/secrets"
+
+// ---------------------------------------------------
+// FILE-BACKED COMPOSE SECRETS
+// ---------------------------------------------------
+
+func composeSecretName(key string) string {
+	return strings.ToLower(key)
+}
+
+// fileSecretKeys returns the env keys that are mounted as compose secrets.
+// Multi-line material such as private keys can't survive env interpolation.
+func fileSecretKeys(meta detect.ProjectMeta) []string {
+	var keys []string
+	for _, k := range detect.SortedKeys(meta.Secrets) {
+		if meta.Secrets[k] == detect.SecretPrivateKey {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+func writeSecretFiles(path string, meta detect.ProjectMeta) error {
+	keys := fileSecretKeys(meta)
+	if len(keys) == 0 {
+		return nil
+	}
+
+	// Compose bind-mounts file secrets with their host owner and mode, so
+	// the files must be world-readable for the image's non-root user; the
+	// 0700 directory keeps them private on the host
+	dir := filepath.Join(path, SecretsDir)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	if err := os.Chmod(dir, 0700); err != nil {
+		return err
+	}
+
+	for _, k := range keys {
+		// Dotenv files hold PEM blocks on one line with \n escapes
+		value := strings.ReplaceAll(meta.Env[k], `\n`, "\n")
+		if !strings.HasSuffix(value, "\n") {
+			value += "\n"
+		}
+
+		file := filepath.Join(dir, composeSecretName(k))
+		if err := os.WriteFile(file, []byte(value), 0644); err != nil {
+			return fmt.Errorf("writing secret %s: %w", k, err)
+		}
+		// WriteFile keeps the mode of a file written by an earlier run
+		if err := os.Chmod(file, 0644); err != nil {
+			return fmt.Errorf("writing secret %s: %w", k, err)
+		}
+	}
+
+	return nil
+}
+
+func serviceSecretsBlock(meta detect.ProjectMeta) string {
+	keys := fileSecretKeys(meta)
+	if len(keys) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("    secrets:\n")
+	for _, k := range keys {
+		sb.WriteString(fmt.Sprintf("      - %s\n", composeSecretName(k)))
+	}
+	return sb.String()
+}
+
+func topLevelSecretsBlock(meta detect.ProjectMeta) string {
+	keys := fileSecretKeys(meta)
+	if len(keys) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\nsecrets:\n")
+	for _, k := range keys {
+		sb.WriteString(fmt.Sprintf("  %s:\n    file: ./%s/%s\n", composeSecretName(k), SecretsDir, composeSecretName(k)))
+	}
+	return sb.String()
+}
+
+// RedactionSummary describes how each secret env value is referenced in
+// the generated compose file, in stable key order.
+func RedactionSummary(meta detect.ProjectMeta) []string {
+	generated := generatedEnvKeys(meta)
+
+	var lines []string
+	for _, k := range detect.SortedKeys(meta.Secrets) {
+		kind := meta.Secrets[k]
+		if generated[k] {
+			lines = append(lines, fmt.Sprintf("%s (%s) -> replaced by the generated value in %s", k, kind, LocalEnvFile))
+			continue
+		}
+		if kind == detect.SecretPrivateKey {
+			// The app sees the key's path, not its value, under a new name
+			lines = append(lines, fmt.Sprintf("%s (%s) -> renamed to %s_FILE=/run/secrets/%s (secret file %s/%s): %s is not set in the container, read the key from that path",
+				k, kind, k, composeSecretName(k), SecretsDir, composeSecretName(k), k))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s (%s) -> ${%s}", k, kind, k))
+	}
+	return lines
+}
@@ -0,0 +1,99 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Info describes the checked-out revision of a repository
+type Info struct {
+	Commit   string // full SHA
+	ShortSHA string
+	Branch   string // empty on a detached HEAD
+	Version  string // nearest semver tag without the "v", e.g. 1.4.2
+	Distance int    // commits since Version; 0 when HEAD is tagged
+	Dirty    bool   // uncommitted changes outside the ignored paths
+}
+
+var (
+	semverTag      = regexp.MustCompile(`^v?(\d+)\.(\d+)\.(\d+)(-[0-9A-Za-z.-]+)?$`)
+	describeOutput = regexp.MustCompile(`^(.+)-(\d+)-g[0-9a-f]+$`)
+)
+
+// Describe reads the revision of the repository at dir. Changes to paths
+// in ignore (files docmake generates itself) don't make it dirty.
+func Describe(dir string, ignore ...string) (Info, error) {
+	var info Info
+
+	commit, err := gitOutput(dir, "rev-parse", "HEAD")
+	if err != nil {
+		return info, err
+	}
+	info.Commit = commit
+	info.ShortSHA, _ = gitOutput(dir, "rev-parse", "--short=12", "HEAD")
+
+	if branch, err := gitOutput(dir, "symbolic-ref", "--short", "-q", "HEAD"); err == nil {
+		info.Branch = branch
+	}
+
+	// Nearest tag that looks like a version, as <tag>-<distance>-g<sha>
+	if desc, err := gitOutput(dir, "describe", "--tags", "--long", "--match", "v[0-9]*", "--match", "[0-9]*"); err == nil {
+		if m := describeOutput.FindStringSubmatch(desc); m != nil && semverTag.MatchString(m[1]) {
+			info.Version = strings.TrimPrefix(m[1], "v")
+			info.Distance, _ = strconv.Atoi(m[2])
+		}
+	}
+
+	status, err := gitOutput(dir, "status", "--porcelain", "--untracked-files=normal")
+	if err != nil {
+		return info, err
+	}
+	for _, line := range strings.Split(status, "\n") {
+		if len(line) < 4 {
+			continue
+		}
+		if !ignored(strings.Trim(line[3:], `"`), ignore) {
+			info.Dirty = true
+			break
+		}
+	}
+
+	return info, nil
+}
+
+// String is a version for the revision: 1.4.2 on a tag, 1.4.2-3-g<sha>
+// past one, the short SHA without tags; "-dirty" when modified
+func (i Info) String() string {
+	v := i.ShortSHA
+	switch {
+	case i.Version != "" && i.Distance == 0:
+		v = i.Version
+	case i.Version != "":
+		v = fmt.Sprintf("%s-%d-g%s", i.Version, i.Distance, i.ShortSHA)
+	}
+	if i.Dirty {
+		v += "-dirty"
+	}
+	return v
+}
+
+// ignored reports whether path is one of ignore or below one of them
+func ignored(path string, ignore []string) bool {
+	for _, p := range ignore {
+		p = strings.TrimSuffix(p, "/")
+		if path == p || strings.HasPrefix(path, p+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func gitOutput(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	return strings.TrimRight(string(out), "\r\n"), err
+}
@@ -0,0 +1,225 @@
+package lint
+
+import (
+	"strings"
+)
+
+var composeFileNames = []string{"docker-compose.yml", "docker-compose.yaml", "compose.yml", "compose.yaml"}
+
+type position struct {
+	Value string
+	Line  int
+	Col   int
+}
+
+type composeService struct {
+	Name  string
+	Line  int
+	Image position
+	Build bool
+	Ports []position // container side of each mapping
+}
+
+type composeFile struct {
+	Version  *position
+	Services []composeService
+}
+
+// parseCompose reads the parts of a compose file the rules need. It is
+// indentation-based and only understands block mappings, block sequences
+// and one-line flow sequences, which covers what docmake and most
+// hand-written files use.
+func parseCompose(content string) composeFile {
+	var cf composeFile
+	var svc *composeService
+
+	inServices := false
+	svcIndent, keyIndent := -1, -1
+	key := "" // current service-level key
+
+	for i, raw := range strings.Split(content, "\n") {
+		line := stripYAMLComment(raw)
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		lineNo := i + 1
+
+		// Top-level keys
+		if indent == 0 {
+			inServices = false
+			k, v, _ := splitKey(trimmed)
+			switch k {
+			case "version":
+				cf.Version = &position{Value: unquote(v), Line: lineNo, Col: 1}
+			case "services":
+				inServices = true
+				svcIndent, keyIndent = -1, -1
+			}
+			continue
+		}
+		if !inServices {
+			continue
+		}
+
+		if svcIndent < 0 {
+			svcIndent = indent
+		}
+
+		// Service name
+		if indent == svcIndent {
+			name, _, _ := splitKey(trimmed)
+			cf.Services = append(cf.Services, composeService{Name: name, Line: lineNo})
+			svc = &cf.Services[len(cf.Services)-1]
+			keyIndent, key = -1, ""
+			continue
+		}
+		if svc == nil {
+			continue
+		}
+
+		if keyIndent < 0 {
+			keyIndent = indent
+		}
+
+		// Service-level key
+		if indent == keyIndent && !strings.HasPrefix(trimmed, "-") {
+			k, v, col := splitKey(trimmed)
+			key = k
+			switch k {
+			case "image":
+				svc.Image = position{Value: unquote(v), Line: lineNo, Col: indent + col + 1}
+			case "build":
+				svc.Build = true
+			case "ports":
+				if strings.HasPrefix(v, "[") {
+					offset := indent + col
+					for _, item := range splitFlowSeq(v) {
+						svc.Ports = append(svc.Ports, position{Value: containerPort(unquote(item.Value)), Line: lineNo, Col: offset + item.Col + 1})
+					}
+				}
+			}
+			continue
+		}
+
+		// Nested content of the current key
+		if key == "ports" {
+			item := trimmed
+			col := indent + 1
+			if strings.HasPrefix(item, "-") {
+				item = strings.TrimSpace(item[1:])
+				col = indent + 3
+			}
+			// Long syntax: "- target: 80"
+			if k, v, vcol := splitKey(item); k == "target" {
+				svc.Ports = append(svc.Ports, position{Value: unquote(v), Line: lineNo, Col: col + vcol})
+				continue
+			}
+			if strings.HasPrefix(trimmed, "-") && !strings.Contains(item, ": ") {
+				svc.Ports = append(svc.Ports, position{Value: containerPort(unquote(item)), Line: lineNo, Col: col})
+			}
+		}
+	}
+
+	return cf
+}
+
+func checkCompose(file string, cf composeFile, exposed []string, opts Options) []Finding {
+	var findings []Finding
+
+	if cf.Version != nil {
+		findings = append(findings, newFinding(RuleObsoleteKey, file, cf.Version.Line, cf.Version.Col,
+			"the top-level \"version\" key is obsolete and ignored by Compose v2; remove it"))
+	}
+
+	for _, svc := range cf.Services {
+		if svc.Image.Value != "" {
+			findings = append(findings, checkImageRef(file, svc.Image.Line, svc.Image.Col, svc.Image.Value)...)
+		}
+
+		isApp := svc.Build || (opts.AppImage != "" && svc.Image.Value == opts.AppImage)
+		if !isApp || len(exposed) == 0 {
+			continue
+		}
+		for _, p := range svc.Ports {
+			if p.Value != "" && !contains(exposed, p.Value) {
+				findings = append(findings, newFinding(RulePortMismatch, file, p.Line, p.Col,
+					"service %q publishes container port %s but the Dockerfile only EXPOSEs %s",
+					svc.Name, p.Value, strings.Join(exposed, ", ")))
+			}
+		}
+	}
+
+	return findings
+}
+
+// ---------------------------------------------------
+// HELPERS
+// ---------------------------------------------------
+
+// containerPort returns the container side of a short port mapping:
+// "8080:80/tcp" -> "80", "127.0.0.1:8080:80" -> "80"
+func containerPort(mapping string) string {
+	mapping = strings.SplitN(mapping, "/", 2)[0]
+	parts := strings.Split(mapping, ":")
+	return parts[len(parts)-1]
+}
+
+// splitKey splits "key: value" and returns the 0-based column of value
+func splitKey(s string) (string, string, int) {
+	i := strings.Index(s, ":")
+	if i < 0 {
+		return strings.TrimSpace(s), "", 0
+	}
+	rest := s[i+1:]
+	value := strings.TrimSpace(rest)
+	return strings.TrimSpace(s[:i]), value, i + 1 + (len(rest) - len(strings.TrimLeft(rest, " ")))
+}
+
+// splitFlowSeq splits a one-line "[a, b]" into items with their 0-based
+// column inside s
+func splitFlowSeq(s string) []position {
+	var items []position
+	inner := strings.TrimSuffix(strings.TrimPrefix(s, "["), "]")
+	offset := 1
+	for _, part := range strings.Split(inner, ",") {
+		lead := len(part) - len(strings.TrimLeft(part, " "))
+		if v := strings.TrimSpace(part); v != "" {
+			items = append(items, position{Value: v, Col: offset + lead})
+		}
+		offset += len(part) + 1
+	}
+	return items
+}
+
+func unquote(s string) string {
+	return strings.Trim(strings.TrimSpace(s), `"'`)
+}
+
+// stripYAMLComment drops a trailing "# ..." outside quotes
+func stripYAMLComment(line string) string {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#' && (i == 0 || line[i-1] == ' '):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
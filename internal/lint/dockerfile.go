@@ -0,0 +1,196 @@
+package lint
+
+import (
+	"regexp"
+	"strings"
+)
+
+// instruction is one logical Dockerfile instruction, continuations joined
+type instruction struct {
+	Cmd  string // upper-cased keyword
+	Args string
+	Line int
+	Col  int // column of Args
+}
+
+type stage struct {
+	Image string
+	Name  string
+	Line  int
+	Col   int
+
+	Instructions []instruction
+}
+
+type dockerfile struct {
+	Stages []stage
+}
+
+// parseDockerfile splits the file into stages of instructions
+func parseDockerfile(content string) dockerfile {
+	var df dockerfile
+	var cur *instruction
+	var buf strings.Builder
+
+	flush := func() {
+		if cur == nil {
+			return
+		}
+		cur.Args = strings.TrimSpace(buf.String())
+		if cur.Cmd == "FROM" {
+			fields := strings.Fields(cur.Args)
+			s := stage{Line: cur.Line, Col: cur.Col}
+			// Skip flags like --platform=...
+			for len(fields) > 0 && strings.HasPrefix(fields[0], "--") {
+				fields = fields[1:]
+			}
+			if len(fields) > 0 {
+				s.Image = fields[0]
+				s.Col = cur.Col + strings.Index(cur.Args, fields[0])
+			}
+			if len(fields) == 3 && strings.EqualFold(fields[1], "AS") {
+				s.Name = fields[2]
+			}
+			df.Stages = append(df.Stages, s)
+		} else if len(df.Stages) > 0 {
+			st := &df.Stages[len(df.Stages)-1]
+			st.Instructions = append(st.Instructions, *cur)
+		}
+		cur = nil
+		buf.Reset()
+	}
+
+	for i, raw := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(raw)
+
+		if cur == nil {
+			if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+				continue
+			}
+			indent := len(raw) - len(strings.TrimLeft(raw, " \t"))
+			kw := strings.Fields(trimmed)[0]
+			cur = &instruction{
+				Cmd:  strings.ToUpper(kw),
+				Line: i + 1,
+				Col:  indent + len(kw) + 2,
+			}
+			trimmed = strings.TrimSpace(trimmed[len(kw):])
+		} else if strings.HasPrefix(trimmed, "#") {
+			// Comments inside a continued instruction are dropped
+			continue
+		}
+
+		if strings.HasSuffix(trimmed, "\\") {
+			buf.WriteString(strings.TrimSuffix(trimmed, "\\") + " ")
+			continue
+		}
+		buf.WriteString(trimmed)
+		flush()
+	}
+	flush()
+
+	return df
+}
+
+// exposedPorts returns the container ports EXPOSEd by the final stage
+func (df dockerfile) exposedPorts() []string {
+	if len(df.Stages) == 0 {
+		return nil
+	}
+
+	var ports []string
+	for _, in := range df.Stages[len(df.Stages)-1].Instructions {
+		if in.Cmd != "EXPOSE" {
+			continue
+		}
+		for _, p := range strings.Fields(in.Args) {
+			ports = append(ports, strings.SplitN(p, "/", 2)[0])
+		}
+	}
+	return ports
+}
+
+// Base images that already run as an unprivileged user
+var nonrootImages = regexp.MustCompile(`:nonroot\b|nginx-unprivileged|^gcr\.io/distroless/.*:nonroot`)
+
+var (
+	aptInstall = regexp.MustCompile(`\bapt(-get)?\s+(-\S+\s+)*install\b`)
+	aptCleanup = regexp.MustCompile(`rm\s+-r?f?r?\s+/var/lib/apt/lists`)
+	apkAdd     = regexp.MustCompile(`\bapk\s+(-\S+\s+)*add\b`)
+	addRemote  = regexp.MustCompile(`^(https?://|git@)|\.(tar|tar\.gz|tgz|tar\.bz2|tar\.xz)$`)
+)
+
+func checkDockerfile(file string, df dockerfile) []Finding {
+	var findings []Finding
+
+	stageNames := map[string]bool{}
+	for _, st := range df.Stages {
+		// FROM <earlier stage> isn't an image reference
+		if !stageNames[st.Image] {
+			findings = append(findings, checkImageRef(file, st.Line, st.Col, st.Image)...)
+		}
+		if st.Name != "" {
+			stageNames[st.Name] = true
+		}
+
+		for _, in := range st.Instructions {
+			switch in.Cmd {
+			case "ADD":
+				if !addFetchesOrExtracts(in.Args) {
+					findings = append(findings, newFinding(RuleAddInsteadCopy, file, in.Line, 1,
+						"use COPY for local files; ADD only for remote URLs or archives to extract"))
+				}
+			case "RUN":
+				if aptInstall.MatchString(in.Args) && !aptCleanup.MatchString(in.Args) {
+					findings = append(findings, newFinding(RulePkgCleanup, file, in.Line, 1,
+						"apt-get install without \"rm -rf /var/lib/apt/lists/*\" in the same RUN keeps the package index in the layer"))
+				}
+				if apkAdd.MatchString(in.Args) && !strings.Contains(in.Args, "--no-cache") {
+					findings = append(findings, newFinding(RulePkgCleanup, file, in.Line, 1,
+						"apk add without --no-cache keeps the package index in the layer"))
+				}
+			}
+		}
+	}
+
+	if len(df.Stages) > 0 {
+		final := df.Stages[len(df.Stages)-1]
+		if user := finalUser(final); user == "" && !nonrootImages.MatchString(final.Image) {
+			findings = append(findings, newFinding(RuleMissingUser, file, final.Line, 1,
+				"final stage runs as root; add a USER instruction with an unprivileged user"))
+		} else if user == "root" || user == "0" || strings.HasPrefix(user, "0:") || strings.HasPrefix(user, "root:") {
+			findings = append(findings, newFinding(RuleMissingUser, file, final.Line, 1,
+				"final stage switches to USER %s; run as an unprivileged user", user))
+		}
+	}
+
+	return findings
+}
+
+// finalUser returns the last USER of a stage
+func finalUser(st stage) string {
+	user := ""
+	for _, in := range st.Instructions {
+		if in.Cmd == "USER" {
+			user = strings.TrimSpace(in.Args)
+		}
+	}
+	return user
+}
+
+// addFetchesOrExtracts reports whether any ADD source is a URL or archive
+func addFetchesOrExtracts(args string) bool {
+	fields := strings.Fields(args)
+	for len(fields) > 0 && strings.HasPrefix(fields[0], "--") {
+		fields = fields[1:]
+	}
+	if len(fields) < 2 {
+		return false
+	}
+	for _, src := range fields[:len(fields)-1] {
+		if addRemote.MatchString(strings.Trim(src, `[]",`)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,187 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Severity of a finding, ordered from least to most serious
+type Severity string
+
+const (
+	SeverityInfo    Severity = "info"
+	SeverityWarning Severity = "warning"
+	SeverityError   Severity = "error"
+)
+
+func (s Severity) rank() int {
+	switch s {
+	case SeverityError:
+		return 3
+	case SeverityWarning:
+		return 2
+	case SeverityInfo:
+		return 1
+	}
+	return 0
+}
+
+// AtLeast reports whether s is as serious as min
+func (s Severity) AtLeast(min Severity) bool {
+	return s.rank() >= min.rank()
+}
+
+// Rule describes one check
+type Rule struct {
+	ID       string
+	Name     string
+	Severity Severity
+}
+
+var (
+	RuleUnpinnedTag    = Rule{"DM001", "unpinned-tag", SeverityWarning}
+	RuleLatestTag      = Rule{"DM002", "latest-tag", SeverityWarning}
+	RuleMissingUser    = Rule{"DM003", "missing-user", SeverityWarning}
+	RuleAddInsteadCopy = Rule{"DM004", "add-instead-of-copy", SeverityWarning}
+	RulePkgCleanup     = Rule{"DM005", "package-cache-not-cleaned", SeverityWarning}
+	RulePortMismatch   = Rule{"DM006", "port-mismatch", SeverityError}
+	RuleObsoleteKey    = Rule{"DM007", "obsolete-version-key", SeverityInfo}
+)
+
+// Rules lists every check, in ID order
+var Rules = []Rule{
+	RuleUnpinnedTag, RuleLatestTag, RuleMissingUser, RuleAddInsteadCopy,
+	RulePkgCleanup, RulePortMismatch, RuleObsoleteKey,
+}
+
+// Finding is one rule violation at a file position (1-based)
+type Finding struct {
+	Rule     string   `json:"rule"`
+	Name     string   `json:"name"`
+	Severity Severity `json:"severity"`
+	File     string   `json:"file"`
+	Line     int      `json:"line"`
+	Column   int      `json:"column"`
+	Message  string   `json:"message"`
+}
+
+func newFinding(rule Rule, file string, line, col int, format string, args ...interface{}) Finding {
+	return Finding{
+		Rule:     rule.ID,
+		Name:     rule.Name,
+		Severity: rule.Severity,
+		File:     file,
+		Line:     line,
+		Column:   col,
+		Message:  fmt.Sprintf(format, args...),
+	}
+}
+
+// Options tunes a lint run
+type Options struct {
+	// AppImage is the image built from the Dockerfile. Compose services
+	// using it (or a build: key) are checked against its EXPOSE ports.
+	AppImage string
+}
+
+// Dir lints the Dockerfile and compose file in dir. Missing files are
+// skipped; at least one must exist.
+func Dir(dir string, opts Options) ([]Finding, error) {
+	var findings []Finding
+	var exposed []string
+	found := false
+
+	dockerfile := filepath.Join(dir, "Dockerfile")
+	if data, err := os.ReadFile(dockerfile); err == nil {
+		found = true
+		df := parseDockerfile(string(data))
+		findings = append(findings, checkDockerfile("Dockerfile", df)...)
+		exposed = df.exposedPorts()
+	}
+
+	for _, name := range composeFileNames {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		found = true
+		findings = append(findings, checkCompose(name, parseCompose(string(data)), exposed, opts)...)
+		break
+	}
+
+	if !found {
+		return nil, fmt.Errorf("no Dockerfile or compose file in %s", dir)
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].File != findings[j].File {
+			return findings[i].File < findings[j].File
+		}
+		return findings[i].Line < findings[j].Line
+	})
+	return findings, nil
+}
+
+// Failed reports whether any finding is at least min severe
+func Failed(findings []Finding, min Severity) bool {
+	for _, f := range findings {
+		if f.Severity.AtLeast(min) {
+			return true
+		}
+	}
+	return false
+}
+
+// ---------------------------------------------------
+// OUTPUT
+// ---------------------------------------------------
+
+// WriteText prints one "file:line:col: ID [severity] message" per finding
+func WriteText(w io.Writer, findings []Finding) {
+	for _, f := range findings {
+		fmt.Fprintf(w, "%s:%d:%d: %s [%s] %s (%s)\n", f.File, f.Line, f.Column, f.Rule, f.Severity, f.Message, f.Name)
+	}
+}
+
+// WriteJSON prints the findings as a JSON array
+func WriteJSON(w io.Writer, findings []Finding) error {
+	if findings == nil {
+		findings = []Finding{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(findings)
+}
+
+// ---------------------------------------------------
+// IMAGE REFERENCES
+// ---------------------------------------------------
+
+// checkImageRef flags references without a tag/digest and "latest" tags
+func checkImageRef(file string, line, col int, ref string) []Finding {
+	if ref == "" || ref == "scratch" || strings.Contains(ref, "$") || strings.Contains(ref, "@sha256:") {
+		return nil
+	}
+
+	name := ref
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+
+	i := strings.LastIndex(name, ":")
+	if i < 0 {
+		return []Finding{newFinding(RuleUnpinnedTag, file, line, col,
+			"image %q has no tag; pin a version (e.g. %s:<version>)", ref, ref)}
+	}
+	if name[i+1:] == "latest" {
+		return []Finding{newFinding(RuleLatestTag, file, line, col,
+			"image %q uses the moving \"latest\" tag; pin a version or digest", ref)}
+	}
+	return nil
+}
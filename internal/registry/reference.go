@@ -0,0 +1,166 @@
+package registry
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DockerHub is the canonical host of Docker Hub references
+const DockerHub = "docker.io"
+
+// Reference is a parsed image reference:
+// [registry/]repository[:tag][@digest]
+type Reference struct {
+	Registry   string // e.g. docker.io, ghcr.io, localhost:5000
+	Repository string // e.g. library/nginx, acme/api
+	Tag        string
+	Digest     string // sha256:...
+}
+
+var (
+	pathComponent = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*$`)
+	tagPattern    = regexp.MustCompile(`^[\w][\w.-]{0,127}$`)
+	digestPattern = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+)
+
+// ParseReference parses and normalises ref the way the Docker CLI does:
+// a missing registry means Docker Hub, single-component Hub names get the
+// "library/" prefix, and a missing tag and digest means "latest".
+func ParseReference(ref string) (Reference, error) {
+	r := Reference{}
+	rest := ref
+
+	if i := strings.Index(rest, "@"); i >= 0 {
+		r.Digest = rest[i+1:]
+		rest = rest[:i]
+		if !digestPattern.MatchString(r.Digest) {
+			return r, fmt.Errorf("invalid digest in %q", ref)
+		}
+	}
+
+	if i := strings.LastIndex(rest, ":"); i > strings.LastIndex(rest, "/") {
+		r.Tag = rest[i+1:]
+		rest = rest[:i]
+		if !tagPattern.MatchString(r.Tag) {
+			return r, fmt.Errorf("invalid tag %q in %q", r.Tag, ref)
+		}
+	}
+
+	r.Registry = DockerHub
+	if i := strings.Index(rest, "/"); i > 0 {
+		first := rest[:i]
+		if strings.ContainsAny(first, ".:") || first == "localhost" {
+			r.Registry = normaliseHost(first)
+			rest = rest[i+1:]
+		}
+	}
+
+	if rest == "" {
+		return r, fmt.Errorf("invalid image reference %q: empty repository", ref)
+	}
+	for _, c := range strings.Split(rest, "/") {
+		if !pathComponent.MatchString(c) {
+			return r, fmt.Errorf("invalid image reference %q: repository must be lowercase letters, digits and separators", ref)
+		}
+	}
+
+	if r.Registry == DockerHub && !strings.Contains(rest, "/") {
+		rest = "library/" + rest
+	}
+	r.Repository = rest
+
+	if r.Tag == "" && r.Digest == "" {
+		r.Tag = "latest"
+	}
+	return r, nil
+}
+
+// normaliseHost maps Docker Hub's aliases to docker.io
+func normaliseHost(host string) string {
+	switch host {
+	case "index.docker.io", "registry-1.docker.io", "registry.hub.docker.com":
+		return DockerHub
+	}
+	return host
+}
+
+// Name is registry/repository without tag or digest
+func (r Reference) Name() string {
+	return r.Registry + "/" + r.Repository
+}
+
+// String is the fully qualified reference
+func (r Reference) String() string {
+	s := r.Name()
+	if r.Tag != "" {
+		s += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		s += "@" + r.Digest
+	}
+	return s
+}
+
+// Familiar is the short form the Docker CLI prints (acme/api:1.0, nginx)
+func (r Reference) Familiar() string {
+	name := r.Name()
+	if r.Registry == DockerHub {
+		name = strings.TrimPrefix(r.Repository, "library/")
+	}
+	if r.Tag != "" {
+		name += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		name += "@" + r.Digest
+	}
+	return name
+}
+
+// WithTag returns a copy with tag set and the digest dropped
+func (r Reference) WithTag(tag string) Reference {
+	r.Tag, r.Digest = tag, ""
+	return r
+}
+
+// WithDigest returns a copy pinned to digest, keeping the tag for readers
+func (r Reference) WithDigest(digest string) Reference {
+	r.Digest = digest
+	return r
+}
+
+// APIHost is the host serving the registry's v2 API
+func (r Reference) APIHost() string {
+	if r.Registry == DockerHub {
+		return "registry-1.docker.io"
+	}
+	return r.Registry
+}
+
+// Insecure reports whether the registry is reached over plain HTTP, which
+// Docker only allows for loopback registries by default
+func (r Reference) Insecure() bool {
+	host := strings.SplitN(r.Registry, ":", 2)[0]
+	return host == "localhost" || host == "127.0.0.1" || host == "::1"
+}
+
+// RepositoryName turns a folder or project name into a valid repository
+// path component: lowercase, invalid runs collapsed to "-"
+func RepositoryName(name string) string {
+	var sb strings.Builder
+	dash := false
+	for _, c := range strings.ToLower(name) {
+		if c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '.' || c == '_' {
+			sb.WriteRune(c)
+			dash = false
+		} else if !dash && sb.Len() > 0 {
+			sb.WriteByte('-')
+			dash = true
+		}
+	}
+	out := strings.Trim(sb.String(), "-._")
+	if out == "" {
+		return "app"
+	}
+	return out
+}
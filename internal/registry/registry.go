@@ -0,0 +1,180 @@
+package registry
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// DockerHubServer is the server address Docker Hub credentials are keyed
+// by in the engine and in ~/.docker/config.json
+const DockerHubServer = "https://index.docker.io/v1/"
+
+// Target is where docmake pushes images: a registry host and a namespace
+// (user, organisation, Harbor project or GitLab group/project path)
+type Target struct {
+	Host      string
+	Namespace string
+}
+
+// NewTarget normalises host (scheme and trailing slash stripped, Docker
+// Hub aliases folded into docker.io) and validates namespace
+func NewTarget(host, namespace string) (Target, error) {
+	host = strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(host, "https://"), "http://"), "/")
+	if host == "" {
+		host = DockerHub
+	}
+	host = normaliseHost(strings.ToLower(host))
+
+	namespace = strings.Trim(namespace, "/")
+	if namespace == "" {
+		return Target{}, fmt.Errorf("no namespace for registry %s (set --namespace)", host)
+	}
+	for _, c := range strings.Split(namespace, "/") {
+		if !pathComponent.MatchString(c) {
+			return Target{}, fmt.Errorf("invalid namespace %q: use lowercase letters, digits and separators", namespace)
+		}
+	}
+
+	return Target{Host: host, Namespace: namespace}, nil
+}
+
+// Image returns the reference of repository name at tag under the target
+func (t Target) Image(name, tag string) (Reference, error) {
+	return ParseReference(fmt.Sprintf("%s/%s/%s:%s", t.Host, t.Namespace, RepositoryName(name), tag))
+}
+
+// ServerAddress is the key credentials for the target are stored under
+func (t Target) ServerAddress() string {
+	return ServerAddress(t.Host)
+}
+
+// ServerAddress maps a registry host to its credential key
+func ServerAddress(host string) string {
+	if normaliseHost(host) == DockerHub {
+		return DockerHubServer
+	}
+	return host
+}
+
+// ---------------------------------------------------
+// REGISTRY KINDS
+// ---------------------------------------------------
+
+var ecrHost = regexp.MustCompile(`^\d{12}\.dkr\.ecr(?:-fips)?\.([a-z0-9-]+)\.amazonaws\.com(?:\.cn)?$`)
+
+// Kind classifies a host so credentials can be looked up the way that
+// registry hands them out: dockerhub, ghcr, gitlab, ecr, local or generic
+// (Harbor, Nexus, self-hosted registry:2, ...)
+func Kind(host string) string {
+	h := strings.SplitN(host, ":", 2)[0]
+	switch {
+	case host == DockerHub:
+		return "dockerhub"
+	case host == "ghcr.io":
+		return "ghcr"
+	case host == "registry.gitlab.com" || (os.Getenv("CI_REGISTRY") != "" && host == os.Getenv("CI_REGISTRY")):
+		return "gitlab"
+	case ecrHost.MatchString(host):
+		return "ecr"
+	case h == "localhost" || h == "127.0.0.1":
+		return "local"
+	}
+	return "generic"
+}
+
+// Label is a human name for the registry in messages
+func Label(host string) string {
+	switch Kind(host) {
+	case "dockerhub":
+		return "Docker Hub"
+	case "ghcr":
+		return "GitHub Container Registry"
+	case "gitlab":
+		return "GitLab Container Registry"
+	case "ecr":
+		return "Amazon ECR (" + host + ")"
+	}
+	return host
+}
+
+// ---------------------------------------------------
+// CREDENTIALS
+// ---------------------------------------------------
+
+// Credentials authenticate against one registry
+type Credentials struct {
+	Username string
+	Password string
+	Source   string // where they came from, for messages
+}
+
+// Empty reports whether no credentials were found
+func (c Credentials) Empty() bool {
+	return c.Username == "" || c.Password == ""
+}
+
+// NeedsAuth reports whether pushing to host requires credentials at all
+func NeedsAuth(host string) bool {
+	return Kind(host) != "local"
+}
+
+// EnvCredentials looks up credentials for host from its conventional
+// environment variables, then from config keys
+// "registry.<host>.username" and "registry.<host>.password-env" (the name
+// of the variable holding the password or token).
+func EnvCredentials(host string, cfg func(string) string) Credentials {
+	pick := func(source string, pairs ...[2]string) Credentials {
+		for _, p := range pairs {
+			if u, pw := os.Getenv(p[0]), os.Getenv(p[1]); u != "" && pw != "" {
+				return Credentials{Username: u, Password: pw, Source: source + " (" + p[0] + ")"}
+			}
+		}
+		return Credentials{}
+	}
+
+	var c Credentials
+	switch Kind(host) {
+	case "dockerhub":
+		c = pick("environment", [2]string{"DOCKERHUB_USERNAME", "DOCKERHUB_PASSWORD"}, [2]string{"DOCKERHUB_USERNAME", "DOCKERHUB_TOKEN"})
+	case "ghcr":
+		c = pick("environment", [2]string{"GHCR_USERNAME", "GHCR_TOKEN"}, [2]string{"GITHUB_ACTOR", "GITHUB_TOKEN"})
+	case "gitlab":
+		c = pick("environment", [2]string{"CI_REGISTRY_USER", "CI_REGISTRY_PASSWORD"}, [2]string{"GITLAB_USERNAME", "GITLAB_TOKEN"})
+	case "ecr":
+		c = ecrCredentials(host)
+	}
+	if !c.Empty() {
+		return c
+	}
+
+	if c = pick("environment", [2]string{"DOCMAKE_REGISTRY_USERNAME", "DOCMAKE_REGISTRY_PASSWORD"}); !c.Empty() {
+		return c
+	}
+
+	if cfg != nil {
+		user := cfg("registry." + host + ".username")
+		if env := cfg("registry." + host + ".password-env"); user != "" && env != "" && os.Getenv(env) != "" {
+			return Credentials{Username: user, Password: os.Getenv(env), Source: "config (" + env + ")"}
+		}
+	}
+
+	return Credentials{}
+}
+
+// ecrCredentials gets a 12-hour token from the AWS CLI; ECR always uses
+// the user name "AWS"
+func ecrCredentials(host string) Credentials {
+	m := ecrHost.FindStringSubmatch(host)
+	if m == nil {
+		return Credentials{}
+	}
+
+	out, err := exec.Command("aws", "ecr", "get-login-password", "--region", m[1]).Output()
+	if err != nil {
+		return Credentials{}
+	}
+	return Credentials{Username: "AWS", Password: strings.TrimSpace(string(out)), Source: "aws ecr get-login-password"}
+}
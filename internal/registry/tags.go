@@ -0,0 +1,107 @@
+package registry
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/tejsvapandey1/docmake/internal/git"
+)
+
+// ---------------------------------------------------
+// TAGS FROM GIT
+// ---------------------------------------------------
+
+// TagKinds lists the tags GitTags can derive
+var TagKinds = []string{"sha", "branch", "semver", "latest"}
+
+// DefaultTags is the tag set pushed when none is configured
+const DefaultTags = "sha,branch,semver,latest"
+
+var invalidTagChars = regexp.MustCompile(`[^\w.-]+`)
+
+// GitTags derives image tags from the revision in info:
+//
+//	sha     short commit SHA
+//	branch  branch name (skipped on a detached HEAD)
+//	semver  1.4.2, 1.4 and 1 when HEAD is tagged v1.4.2;
+//	        1.4.2-3-g<sha> when it is 3 commits past it
+//	latest  latest
+//
+// A dirty tree gets "-dirty" sha and branch tags and no semver or latest
+// tags, so release pointers never name uncommitted code. Tags come in the
+// order of kinds.
+func GitTags(info git.Info, kinds []string) ([]string, error) {
+	if info.Commit == "" {
+		return []string{"latest"}, nil
+	}
+
+	suffix := ""
+	if info.Dirty {
+		suffix = "-dirty"
+	}
+
+	var tags []string
+	add := func(tag string) {
+		tag = SanitizeTag(tag)
+		for _, t := range tags {
+			if t == tag {
+				return
+			}
+		}
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	for _, kind := range kinds {
+		switch strings.TrimSpace(kind) {
+		case "":
+		case "sha":
+			add(info.ShortSHA + suffix)
+		case "branch":
+			if info.Branch != "" {
+				add(info.Branch + suffix)
+			}
+		case "semver":
+			if info.Version == "" || info.Dirty {
+				continue
+			}
+			if info.Distance > 0 {
+				add(fmt.Sprintf("%s-%d-g%s", info.Version, info.Distance, info.ShortSHA))
+				continue
+			}
+			add(info.Version)
+			// Floating minor and major tags only for final releases
+			if !strings.Contains(info.Version, "-") {
+				parts := strings.SplitN(info.Version, ".", 3)
+				add(parts[0] + "." + parts[1])
+				if parts[0] != "0" {
+					add(parts[0])
+				}
+			}
+		case "latest":
+			if !info.Dirty {
+				add("latest")
+			}
+		default:
+			return nil, fmt.Errorf("unknown tag kind %q (valid: %s)", kind, strings.Join(TagKinds, ", "))
+		}
+	}
+
+	// Always push something traceable
+	if len(tags) == 0 {
+		add(info.ShortSHA + suffix)
+	}
+	return tags, nil
+}
+
+// SanitizeTag turns s into a valid tag: invalid runs become "-", leading
+// separators are dropped and the result is cut to 128 characters
+func SanitizeTag(s string) string {
+	s = strings.TrimLeft(invalidTagChars.ReplaceAllString(s, "-"), ".-")
+	if len(s) > 128 {
+		s = s[:128]
+	}
+	return s
+}
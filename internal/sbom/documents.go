@@ -0,0 +1,241 @@
+package sbom
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// Media types of the two formats, also used as OCI artifact types
+const (
+	SPDXMediaType      = "application/spdx+json"
+	CycloneDXMediaType = "application/vnd.cyclonedx+json"
+)
+
+// Image is what an SBOM describes
+type Image struct {
+	Name   string // repository, e.g. ghcr.io/acme/api
+	Digest string // manifest digest, when pushed
+	Tag    string
+
+	// Platform is the os/arch of a per-platform manifest in an image
+	// index, "" for single-platform images
+	Platform string
+}
+
+func (img Image) version() string {
+	if img.Digest != "" {
+		return img.Digest
+	}
+	return img.Tag
+}
+
+// ---------------------------------------------------
+// SPDX 2.3
+// ---------------------------------------------------
+
+type spdxDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	Packages          []spdxPackage      `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	Name             string       `json:"name"`
+	SPDXID           string       `json:"SPDXID"`
+	VersionInfo      string       `json:"versionInfo,omitempty"`
+	DownloadLocation string       `json:"downloadLocation"`
+	FilesAnalyzed    bool         `json:"filesAnalyzed"`
+	PrimaryPurpose   string       `json:"primaryPackagePurpose,omitempty"`
+	ExternalRefs     []spdxExtRef `json:"externalRefs,omitempty"`
+	Comment          string       `json:"comment,omitempty"`
+}
+
+type spdxExtRef struct {
+	Category string `json:"referenceCategory"`
+	Type     string `json:"referenceType"`
+	Locator  string `json:"referenceLocator"`
+}
+
+type spdxRelationship struct {
+	Element string `json:"spdxElementId"`
+	Type    string `json:"relationshipType"`
+	Related string `json:"relatedSpdxElement"`
+}
+
+var spdxIDChars = regexp.MustCompile(`[^A-Za-z0-9.-]+`)
+
+// SPDX renders comps as an SPDX 2.3 JSON document
+func SPDX(img Image, comps []Component, created time.Time) ([]byte, error) {
+	doc := spdxDocument{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              img.Name,
+		DocumentNamespace: "https://spdx.org/spdxdocs/docmake/" + img.Name + "-" + newUUID(),
+		CreationInfo: spdxCreationInfo{
+			Created:  created.UTC().Format(time.RFC3339),
+			Creators: []string{"Tool: docmake"},
+		},
+	}
+
+	root := spdxPackage{
+		Name:             img.Name,
+		SPDXID:           "SPDXRef-Image",
+		VersionInfo:      img.version(),
+		DownloadLocation: "NOASSERTION",
+		PrimaryPurpose:   "CONTAINER",
+	}
+	if img.Platform != "" {
+		root.Comment = "platform " + img.Platform
+	}
+	doc.Packages = append(doc.Packages, root)
+	doc.Relationships = append(doc.Relationships, spdxRelationship{"SPDXRef-DOCUMENT", "DESCRIBES", root.SPDXID})
+
+	for i, c := range comps {
+		pkg := spdxPackage{
+			Name:             c.Name,
+			SPDXID:           fmt.Sprintf("SPDXRef-Package-%s-%d", spdxIDChars.ReplaceAllString(c.Ecosystem+"-"+c.Name, "-"), i),
+			VersionInfo:      c.Version,
+			DownloadLocation: "NOASSERTION",
+			ExternalRefs:     []spdxExtRef{{"PACKAGE-MANAGER", "purl", c.PURL()}},
+		}
+		if c.Dev {
+			pkg.Comment = "development dependency"
+		}
+		doc.Packages = append(doc.Packages, pkg)
+		doc.Relationships = append(doc.Relationships, spdxRelationship{root.SPDXID, "CONTAINS", pkg.SPDXID})
+	}
+
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// ---------------------------------------------------
+// CYCLONEDX 1.5
+// ---------------------------------------------------
+
+type cdxDocument struct {
+	BOMFormat    string         `json:"bomFormat"`
+	SpecVersion  string         `json:"specVersion"`
+	SerialNumber string         `json:"serialNumber"`
+	Version      int            `json:"version"`
+	Metadata     cdxMetadata    `json:"metadata"`
+	Components   []cdxComponent `json:"components"`
+}
+
+type cdxMetadata struct {
+	Timestamp string `json:"timestamp"`
+	Tools     struct {
+		Components []cdxComponent `json:"components"`
+	} `json:"tools"`
+	Component cdxComponent `json:"component"`
+}
+
+type cdxComponent struct {
+	BOMRef     string        `json:"bom-ref,omitempty"`
+	Type       string        `json:"type"`
+	Name       string        `json:"name"`
+	Version    string        `json:"version,omitempty"`
+	PURL       string        `json:"purl,omitempty"`
+	Scope      string        `json:"scope,omitempty"`
+	Properties []cdxProperty `json:"properties,omitempty"`
+}
+
+type cdxProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// CycloneDX renders comps as a CycloneDX 1.5 JSON document
+func CycloneDX(img Image, comps []Component, created time.Time) ([]byte, error) {
+	doc := cdxDocument{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.5",
+		SerialNumber: "urn:uuid:" + newUUID(),
+		Version:      1,
+	}
+	doc.Metadata.Timestamp = created.UTC().Format(time.RFC3339)
+	doc.Metadata.Tools.Components = []cdxComponent{{Type: "application", Name: "docmake"}}
+	doc.Metadata.Component = cdxComponent{BOMRef: img.Name, Type: "container", Name: img.Name, Version: img.version()}
+	if img.Platform != "" {
+		doc.Metadata.Component.Properties = []cdxProperty{{Name: "docmake:platform", Value: img.Platform}}
+	}
+
+	doc.Components = []cdxComponent{}
+	for _, c := range comps {
+		comp := cdxComponent{
+			BOMRef:     c.PURL(),
+			Type:       "library",
+			Name:       c.Name,
+			Version:    c.Version,
+			PURL:       c.PURL(),
+			Properties: []cdxProperty{{Name: "docmake:source", Value: c.Source}},
+		}
+		if c.Dev {
+			comp.Scope = "optional"
+		}
+		doc.Components = append(doc.Components, comp)
+	}
+
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// ---------------------------------------------------
+// OUTPUT
+// ---------------------------------------------------
+
+// Documents are the rendered SBOMs of one image
+type Documents struct {
+	SPDX      []byte
+	CycloneDX []byte
+}
+
+// Generate renders both formats
+func Generate(img Image, comps []Component) (Documents, error) {
+	now := time.Now()
+	spdx, err := SPDX(img, comps, now)
+	if err != nil {
+		return Documents{}, err
+	}
+	cdx, err := CycloneDX(img, comps, now)
+	return Documents{SPDX: spdx, CycloneDX: cdx}, err
+}
+
+// Write saves the documents in dir as <name>.spdx.json and
+// <name>.cdx.json and returns their paths
+func (d Documents) Write(dir, name string) ([]string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	paths := []string{filepath.Join(dir, name+".spdx.json"), filepath.Join(dir, name+".cdx.json")}
+	for i, data := range [][]byte{d.SPDX, d.CycloneDX} {
+		if err := os.WriteFile(paths[i], append(data, '\n'), 0644); err != nil {
+			return nil, err
+		}
+	}
+	return paths, nil
+}
+
+// newUUID returns a random (version 4) UUID
+func newUUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
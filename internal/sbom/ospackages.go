@@ -0,0 +1,183 @@
+package sbom
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+// ---------------------------------------------------
+// OS PACKAGES FROM THE IMAGE
+// ---------------------------------------------------
+
+// Package databases copied out of the image. status.d is where distroless
+// images keep theirs, since they ship without dpkg. /etc/os-release is
+// often a symlink to the /usr/lib copy, which docker cp would not follow.
+var packageDBs = []string{"/etc/os-release", "/usr/lib/os-release", "/var/lib/dpkg/status", "/var/lib/dpkg/status.d", "/lib/apk/db/installed"}
+
+// OSPackages lists the Debian or Alpine packages installed in image for
+// platform ("" for the host's). tool is a docker-compatible CLI (docker
+// or podman): a stopped container is created and the package databases
+// are copied out of it, so images without a shell work too.
+func OSPackages(tool, image, platform string) ([]Component, error) {
+	args := []string{"create"}
+	if platform != "" {
+		args = append(args, "--platform", platform)
+	}
+	out, err := exec.Command(tool, append(args, image)...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s create %s: %w", tool, image, err)
+	}
+	id := strings.TrimSpace(string(out))
+	defer exec.Command(tool, "rm", id).Run()
+
+	files := map[string][]byte{}
+	for _, path := range packageDBs {
+		archive, err := exec.Command(tool, "cp", id+":"+path, "-").Output()
+		if err != nil {
+			continue // not in this image
+		}
+		if err := untar(archive, files); err != nil {
+			return nil, err
+		}
+	}
+
+	distro, release := osRelease(files["os-release"])
+	var comps []Component
+	for name, data := range files {
+		switch {
+		case name == "status" || strings.HasPrefix(name, "status.d/"):
+			comps = append(comps, parseDpkgStatus(data, distro)...)
+		case name == "installed":
+			comps = append(comps, parseApkInstalled(data)...)
+		}
+	}
+	for i := range comps {
+		comps[i].Release = release
+	}
+	return sorted(dedupe(comps)), nil
+}
+
+// untar stores the regular files of archive in files by their path inside
+// the copied directory (docker cp names entries after the source base name)
+func untar(archive []byte, files map[string][]byte) error {
+	tr := tar.NewReader(bytes.NewReader(archive))
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return err
+		}
+		files[strings.TrimPrefix(hdr.Name, "./")] = data
+	}
+}
+
+// osRelease reads ID and VERSION_ID from os-release, defaulting to debian
+func osRelease(data []byte) (id, version string) {
+	id = "debian"
+	for _, line := range strings.Split(string(data), "\n") {
+		if v, ok := strings.CutPrefix(line, "ID="); ok {
+			id = strings.Trim(v, `"`)
+		}
+		if v, ok := strings.CutPrefix(line, "VERSION_ID="); ok {
+			version = strings.Trim(v, `"`)
+		}
+	}
+	return id, version
+}
+
+// parseDpkgStatus reads installed packages from a dpkg status file
+func parseDpkgStatus(data []byte, distro string) []Component {
+	var comps []Component
+	var c Component
+	installed := true
+
+	flush := func() {
+		if c.Name != "" && installed {
+			c.Ecosystem, c.Distro, c.Source = "deb", distro, "/var/lib/dpkg"
+			comps = append(comps, c)
+		}
+		c, installed = Component{}, true
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			flush()
+			continue
+		}
+		key, value, ok := strings.Cut(line, ": ")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "Package":
+			c.Name = value
+		case "Version":
+			c.Version = value
+		case "Architecture":
+			c.Arch = value
+		case "Source":
+			// "openssl (3.0.11-1)": advisories are filed per source package
+			if f := strings.Fields(value); len(f) > 0 {
+				c.Origin = f[0]
+			}
+		case "Status":
+			installed = strings.HasSuffix(value, " installed")
+		}
+	}
+	flush()
+	return comps
+}
+
+// parseApkInstalled reads /lib/apk/db/installed (P: name, V: version)
+func parseApkInstalled(data []byte) []Component {
+	var comps []Component
+	var c Component
+
+	flush := func() {
+		if c.Name != "" {
+			c.Ecosystem, c.Distro, c.Source = "apk", "alpine", "/lib/apk/db/installed"
+			comps = append(comps, c)
+		}
+		c = Component{}
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "" {
+			flush()
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "P":
+			c.Name = value
+		case "V":
+			c.Version = value
+		case "A":
+			c.Arch = value
+		case "o":
+			c.Origin = value
+		}
+	}
+	flush()
+	return comps
+}
@@ -0,0 +1,118 @@
+package sbom
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/tejsvapandey1/docmake/internal/detect"
+)
+
+// Component is one package in the image
+type Component struct {
+	Name      string
+	Version   string
+	Ecosystem string // npm, pypi, golang, deb or apk
+	Distro    string // debian, ubuntu, alpine for OS packages
+	Release   string // distro VERSION_ID, e.g. 12 or 3.19.1
+	Origin    string // source package an OS package was built from
+	Arch      string
+	Dev       bool   // development dependency (still installed by npm install)
+	Source    string // file the component was read from
+}
+
+// PURL is the package URL identifying the component
+func (c Component) PURL() string {
+	name := c.Name
+	switch c.Ecosystem {
+	case "npm":
+		// Scoped packages: pkg:npm/%40scope/name
+		if strings.HasPrefix(name, "@") {
+			name = "%40" + name[1:]
+		}
+	case "pypi":
+		name = detect.NormalizePyPI(name)
+	case "deb", "apk":
+		name = c.Distro + "/" + name
+	}
+
+	purl := "pkg:" + c.Ecosystem + "/" + name
+	if c.Version != "" {
+		purl += "@" + url.PathEscape(c.Version)
+	}
+	var qualifiers []string
+	if c.Arch != "" {
+		qualifiers = append(qualifiers, "arch="+c.Arch)
+	}
+	if c.Release != "" {
+		qualifiers = append(qualifiers, "distro="+c.Distro+"-"+c.Release)
+	}
+	if len(qualifiers) > 0 {
+		purl += "?" + strings.Join(qualifiers, "&")
+	}
+	return purl
+}
+
+// ---------------------------------------------------
+// LANGUAGE PACKAGES
+// ---------------------------------------------------
+
+// FromRepo lists the language dependencies detect reads from the repo's
+// manifests and lock files. Versions come from package-lock.json, exact
+// pins and go.mod; unpinned dependencies are listed without a version.
+func FromRepo(path string) []Component {
+	var comps []Component
+	for _, p := range detect.ReadManifests(path).Packages {
+		comps = append(comps, Component{
+			Name: p.Name, Version: p.Version, Ecosystem: p.Ecosystem, Dev: p.Dev, Source: p.Source,
+		})
+	}
+	return dedupe(comps)
+}
+
+// ---------------------------------------------------
+// HELPERS
+// ---------------------------------------------------
+
+// dedupe drops repeated name@version pairs (npm nests duplicates)
+func dedupe(comps []Component) []Component {
+	seen := map[string]bool{}
+	var out []Component
+	for _, c := range comps {
+		key := c.Ecosystem + "/" + c.Name + "@" + c.Version
+		if !seen[key] {
+			seen[key] = true
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func sorted(comps []Component) []Component {
+	sort.Slice(comps, func(i, j int) bool {
+		if comps[i].Name != comps[j].Name {
+			return comps[i].Name < comps[j].Name
+		}
+		return comps[i].Version < comps[j].Version
+	})
+	return comps
+}
+
+// Summary counts components per ecosystem, e.g. "12 npm, 80 deb"
+func Summary(comps []Component) string {
+	counts := map[string]int{}
+	var order []string
+	for _, c := range comps {
+		if counts[c.Ecosystem] == 0 {
+			order = append(order, c.Ecosystem)
+		}
+		counts[c.Ecosystem]++
+	}
+
+	var parts []string
+	for _, eco := range order {
+		parts = append(parts, fmt.Sprintf("%d %s", counts[eco], eco))
+	}
+	return strings.Join(parts, ", ")
+}
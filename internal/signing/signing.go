@@ -0,0 +1,216 @@
+package signing
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tejsvapandey1/docmake/internal/registry"
+)
+
+// Key pair file names, as cosign names its own
+const (
+	PrivateKeyFile = "cosign.key"
+	PublicKeyFile  = "cosign.pub"
+)
+
+// PayloadType marks a cosign simple signing payload
+const PayloadType = "cosign container image signature"
+
+// ---------------------------------------------------
+// KEYS
+// ---------------------------------------------------
+
+// GenerateKeyPair writes a new ECDSA P-256 private key to privPath and
+// its public key next to it (cosign.key, cosign.pub) and returns the
+// public key path. Existing keys are never overwritten.
+func GenerateKeyPair(privPath string) (string, error) {
+	pubPath := strings.TrimSuffix(privPath, filepath.Ext(privPath)) + ".pub"
+	for _, p := range []string{privPath, pubPath} {
+		if _, err := os.Stat(p); err == nil {
+			return "", fmt.Errorf("%s already exists", p)
+		}
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", err
+	}
+	privDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return "", err
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(privPath), 0700); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(privPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}), 0600); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(pubPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}), 0644); err != nil {
+		return "", err
+	}
+	return pubPath, nil
+}
+
+// LoadPrivateKey reads an unencrypted PEM ECDSA key (PKCS#8 or SEC 1)
+func LoadPrivateKey(path string) (*ecdsa.PrivateKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if ec, ok := key.(*ecdsa.PrivateKey); ok {
+			return ec, nil
+		}
+		return nil, fmt.Errorf("%s: not an ECDSA key", path)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	case "ENCRYPTED SIGSTORE PRIVATE KEY", "ENCRYPTED COSIGN PRIVATE KEY":
+		return nil, fmt.Errorf("%s: password-protected cosign keys aren't supported; use a key from \"docmake sign --generate-key\"", path)
+	}
+	return nil, fmt.Errorf("%s: unexpected PEM block %q", path, block.Type)
+}
+
+// LoadPublicKey reads a PEM ECDSA public key, such as cosign.pub
+func LoadPublicKey(path string) (*ecdsa.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	if block.Type != "PUBLIC KEY" {
+		return nil, fmt.Errorf("%s: unexpected PEM block %q", path, block.Type)
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if ec, ok := key.(*ecdsa.PublicKey); ok {
+		return ec, nil
+	}
+	return nil, fmt.Errorf("%s: not an ECDSA key", path)
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM data", path)
+	}
+	return block, nil
+}
+
+// ---------------------------------------------------
+// SIMPLE SIGNING PAYLOAD
+// ---------------------------------------------------
+
+// Payload is the simple signing document cosign signs: it binds the
+// signature to one manifest digest
+type Payload struct {
+	Critical struct {
+		Identity struct {
+			DockerReference string `json:"docker-reference"`
+		} `json:"identity"`
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+		Type string `json:"type"`
+	} `json:"critical"`
+	Optional map[string]string `json:"optional"`
+}
+
+// NewPayload describes the image repository@digest
+func NewPayload(repository, digest string) ([]byte, error) {
+	var p Payload
+	p.Critical.Identity.DockerReference = cosignRepository(repository)
+	p.Critical.Image.DockerManifestDigest = digest
+	p.Critical.Type = PayloadType
+	return json.Marshal(p)
+}
+
+// cosign names Docker Hub repositories index.docker.io/...
+func cosignRepository(repository string) string {
+	if rest, ok := strings.CutPrefix(repository, registry.DockerHub+"/"); ok {
+		return "index.docker.io/" + rest
+	}
+	return repository
+}
+
+// sameRepository compares repository names with Docker Hub's aliases
+// (index.docker.io, registry-1.docker.io) folded into docker.io
+func sameRepository(a, b string) bool {
+	ra, errA := registry.ParseReference(a)
+	rb, errB := registry.ParseReference(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	return ra.Name() == rb.Name()
+}
+
+// ---------------------------------------------------
+// SIGN / VERIFY
+// ---------------------------------------------------
+
+// Sign returns the base64 ASN.1 ECDSA signature of payload's SHA-256
+func Sign(key *ecdsa.PrivateKey, payload []byte) (string, error) {
+	sum := sha256.Sum256(payload)
+	sig, err := ecdsa.SignASN1(rand.Reader, key, sum[:])
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
+
+// Verify checks sig over payload with key and that the payload signs
+// repository@digest. It returns the parsed payload.
+func Verify(key *ecdsa.PublicKey, payload []byte, sig, repository, digest string) (Payload, error) {
+	var p Payload
+
+	raw, err := base64.StdEncoding.DecodeString(sig)
+	if err != nil {
+		return p, fmt.Errorf("malformed signature: %w", err)
+	}
+	sum := sha256.Sum256(payload)
+	if !ecdsa.VerifyASN1(key, sum[:], raw) {
+		return p, errors.New("signature does not match the key")
+	}
+
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return p, fmt.Errorf("malformed payload: %w", err)
+	}
+	if p.Critical.Type != PayloadType {
+		return p, fmt.Errorf("unexpected payload type %q", p.Critical.Type)
+	}
+	if p.Critical.Image.DockerManifestDigest != digest {
+		return p, fmt.Errorf("signature is for %s, not %s", p.Critical.Image.DockerManifestDigest, digest)
+	}
+	// The same digest pushed to another repository is a different image
+	if !sameRepository(p.Critical.Identity.DockerReference, repository) {
+		return p, fmt.Errorf("signature is for repository %s, not %s", p.Critical.Identity.DockerReference, repository)
+	}
+	return p, nil
+}
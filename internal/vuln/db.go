@@ -0,0 +1,139 @@
+package vuln
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ---------------------------------------------------
+// OSV ADVISORIES
+// ---------------------------------------------------
+
+// Advisory is the part of an OSV record the scanner uses
+// (https://ossf.github.io/osv-schema/)
+type Advisory struct {
+	ID        string   `json:"id"`
+	Summary   string   `json:"summary"`
+	Aliases   []string `json:"aliases"`
+	Withdrawn string   `json:"withdrawn"`
+	Affected  []struct {
+		Package struct {
+			Ecosystem string `json:"ecosystem"`
+			Name      string `json:"name"`
+		} `json:"package"`
+		Ranges []struct {
+			Type   string `json:"type"`
+			Events []struct {
+				Introduced   string `json:"introduced"`
+				Fixed        string `json:"fixed"`
+				LastAffected string `json:"last_affected"`
+			} `json:"events"`
+		} `json:"ranges"`
+		Versions          []string `json:"versions"`
+		EcosystemSpecific struct {
+			Severity string `json:"severity"`
+		} `json:"ecosystem_specific"`
+	} `json:"affected"`
+	Severity []struct {
+		Type  string `json:"type"`
+		Score string `json:"score"`
+	} `json:"severity"`
+	DatabaseSpecific struct {
+		Severity string `json:"severity"`
+	} `json:"database_specific"`
+}
+
+// DB indexes advisories by ecosystem and package name
+type DB struct {
+	byPackage map[string][]*Advisory
+	Count     int
+}
+
+func packageKey(ecosystem, name string) string {
+	return strings.ToLower(ecosystem + "/" + name)
+}
+
+// Load reads every OSV record under dir: loose *.json files and the
+// per-ecosystem all.zip archives the OSV project publishes. Nothing is
+// fetched from the network.
+func Load(dir string) (*DB, error) {
+	db := &DB{byPackage: map[string][]*Advisory{}}
+
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		switch strings.ToLower(filepath.Ext(p)) {
+		case ".json":
+			data, err := os.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			return db.add(data, p)
+		case ".zip":
+			return db.addZip(p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if db.Count == 0 {
+		return nil, fmt.Errorf("no OSV advisories found in %s", dir)
+	}
+	return db, nil
+}
+
+func (db *DB) addZip(path string) error {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	for _, f := range zr.File {
+		if !strings.HasSuffix(f.Name, ".json") {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+		if err := db.add(data, path+":"+f.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (db *DB) add(data []byte, source string) error {
+	var adv Advisory
+	if err := json.Unmarshal(data, &adv); err != nil {
+		return fmt.Errorf("%s: %w", source, err)
+	}
+	if adv.ID == "" || adv.Withdrawn != "" {
+		return nil
+	}
+
+	seen := map[string]bool{}
+	for _, aff := range adv.Affected {
+		key := packageKey(aff.Package.Ecosystem, aff.Package.Name)
+		if !seen[key] {
+			seen[key] = true
+			db.byPackage[key] = append(db.byPackage[key], &adv)
+		}
+	}
+	db.Count++
+	return nil
+}
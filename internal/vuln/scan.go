@@ -0,0 +1,179 @@
+package vuln
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/tejsvapandey1/docmake/internal/sbom"
+)
+
+// ---------------------------------------------------
+// MATCHING
+// ---------------------------------------------------
+
+// Finding is an advisory affecting one component
+type Finding struct {
+	ID        string
+	Aliases   []string
+	Summary   string
+	Severity  Severity
+	Package   string
+	Version   string
+	Ecosystem string
+	Fixed     string // first fixed version, when known
+}
+
+// Scan matches components against db. Components without a version
+// can't be matched and are skipped.
+func Scan(db *DB, comps []sbom.Component) []Finding {
+	var findings []Finding
+
+	for _, c := range comps {
+		ecosystem := osvEcosystem(c)
+		if ecosystem == "" || c.Version == "" {
+			continue
+		}
+
+		names := []string{c.Name}
+		if c.Origin != "" && c.Origin != c.Name {
+			names = append(names, c.Origin)
+		}
+
+		seen := map[string]bool{}
+		for _, name := range names {
+			for _, adv := range db.byPackage[packageKey(ecosystem, name)] {
+				fixed, ok := affects(adv, ecosystem, name, c.Version)
+				if !ok || seen[adv.ID] {
+					continue
+				}
+				seen[adv.ID] = true
+				findings = append(findings, Finding{
+					ID: adv.ID, Aliases: adv.Aliases, Summary: adv.Summary,
+					Severity: severityOf(adv), Package: c.Name, Version: c.Version,
+					Ecosystem: ecosystem, Fixed: fixed,
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Severity != findings[j].Severity {
+			return findings[i].Severity > findings[j].Severity
+		}
+		return findings[i].Package < findings[j].Package
+	})
+	return findings
+}
+
+// osvEcosystem names the OSV ecosystem of a component. OS advisories are
+// per release: Debian:12, Alpine:v3.19.
+func osvEcosystem(c sbom.Component) string {
+	switch c.Ecosystem {
+	case "npm":
+		return "npm"
+	case "pypi":
+		return "PyPI"
+	case "golang":
+		return "Go"
+	case "deb":
+		if c.Distro == "debian" && c.Release != "" {
+			return "Debian:" + strings.SplitN(c.Release, ".", 2)[0]
+		}
+	case "apk":
+		if parts := strings.SplitN(c.Release, ".", 3); len(parts) >= 2 {
+			return "Alpine:v" + parts[0] + "." + parts[1]
+		}
+	}
+	return ""
+}
+
+// affects reports whether version of the package is affected by adv and
+// returns the first fixed version of the matching range
+func affects(adv *Advisory, ecosystem, name, version string) (string, bool) {
+	cmp := comparator(ecosystem)
+
+	for _, aff := range adv.Affected {
+		if !strings.EqualFold(aff.Package.Ecosystem, ecosystem) || !strings.EqualFold(aff.Package.Name, name) {
+			continue
+		}
+
+		for _, v := range aff.Versions {
+			if cmp(v, version) == 0 {
+				return "", true
+			}
+		}
+
+		for _, r := range aff.Ranges {
+			if r.Type == "GIT" {
+				continue
+			}
+			// Events are ordered; each "introduced" opens an interval
+			// closed by the next "fixed" or "last_affected"
+			inRange := false
+			for _, e := range r.Events {
+				switch {
+				case e.Introduced != "":
+					inRange = e.Introduced == "0" || cmp(version, e.Introduced) >= 0
+				case e.Fixed != "" && inRange:
+					if cmp(version, e.Fixed) < 0 {
+						return e.Fixed, true
+					}
+					inRange = false
+				case e.LastAffected != "" && inRange:
+					if cmp(version, e.LastAffected) <= 0 {
+						return "", true
+					}
+					inRange = false
+				}
+			}
+			if inRange {
+				return "", true
+			}
+		}
+	}
+	return "", false
+}
+
+// ---------------------------------------------------
+// GATE
+// ---------------------------------------------------
+
+// Blocking returns the findings at or above threshold
+func Blocking(findings []Finding, threshold Severity) []Finding {
+	var out []Finding
+	for _, f := range findings {
+		if f.Severity >= threshold {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// Counts tallies findings per severity, e.g. "2 critical, 1 high"
+func Counts(findings []Finding) string {
+	counts := make([]int, len(severityNames))
+	for _, f := range findings {
+		counts[f.Severity]++
+	}
+
+	var parts []string
+	for s := Critical; s >= Unknown; s-- {
+		if counts[s] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[s], s))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// WriteText prints one line per finding
+func WriteText(w io.Writer, findings []Finding) {
+	for _, f := range findings {
+		fix := "no fix"
+		if f.Fixed != "" {
+			fix = "fixed in " + f.Fixed
+		}
+		fmt.Fprintf(w, "  [%s] %s %s@%s (%s): %s\n", f.Severity, f.ID, f.Package, f.Version, fix, f.Summary)
+	}
+}
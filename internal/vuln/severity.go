@@ -0,0 +1,142 @@
+package vuln
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// ---------------------------------------------------
+// SEVERITY
+// ---------------------------------------------------
+
+// Severity levels, ordered
+type Severity int
+
+const (
+	Unknown Severity = iota
+	Low
+	Medium
+	High
+	Critical
+)
+
+var severityNames = []string{"unknown", "low", "medium", "high", "critical"}
+
+func (s Severity) String() string {
+	return severityNames[s]
+}
+
+// ParseSeverity reads a level name; "moderate" (GitHub's word) is medium
+func ParseSeverity(name string) (Severity, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "moderate":
+		return Medium, nil
+	case "important":
+		return High, nil
+	}
+	for i, n := range severityNames {
+		if strings.EqualFold(name, n) {
+			return Severity(i), nil
+		}
+	}
+	return Unknown, fmt.Errorf("unknown severity %q (valid: %s)", name, strings.Join(severityNames, ", "))
+}
+
+// severityOf rates an advisory: the database's own rating first, then
+// the CVSS v3 base score
+func severityOf(adv *Advisory) Severity {
+	if s, err := ParseSeverity(adv.DatabaseSpecific.Severity); err == nil && s != Unknown {
+		return s
+	}
+	for _, aff := range adv.Affected {
+		if s, err := ParseSeverity(aff.EcosystemSpecific.Severity); err == nil && s != Unknown {
+			return s
+		}
+	}
+	for _, sev := range adv.Severity {
+		if sev.Type == "CVSS_V3" {
+			if score, ok := cvss3Score(sev.Score); ok {
+				return scoreSeverity(score)
+			}
+		}
+	}
+	return Unknown
+}
+
+// scoreSeverity maps a CVSS score to its qualitative rating
+func scoreSeverity(score float64) Severity {
+	switch {
+	case score >= 9:
+		return Critical
+	case score >= 7:
+		return High
+	case score >= 4:
+		return Medium
+	case score > 0:
+		return Low
+	}
+	return Unknown
+}
+
+// cvss3Score computes the base score of a CVSS v3.x vector
+// ("CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H")
+func cvss3Score(vector string) (float64, bool) {
+	m := map[string]string{}
+	for _, part := range strings.Split(vector, "/")[1:] {
+		if k, v, ok := strings.Cut(part, ":"); ok {
+			m[k] = v
+		}
+	}
+
+	weights := map[string]map[string]float64{
+		"AV": {"N": 0.85, "A": 0.62, "L": 0.55, "P": 0.2},
+		"AC": {"L": 0.77, "H": 0.44},
+		"UI": {"N": 0.85, "R": 0.62},
+		"C":  {"H": 0.56, "L": 0.22, "N": 0},
+		"I":  {"H": 0.56, "L": 0.22, "N": 0},
+		"A":  {"H": 0.56, "L": 0.22, "N": 0},
+	}
+	w := map[string]float64{}
+	for k, table := range weights {
+		v, ok := table[m[k]]
+		if !ok {
+			return 0, false
+		}
+		w[k] = v
+	}
+
+	changed := m["S"] == "C"
+	pr := map[string]float64{"N": 0.85, "L": 0.62, "H": 0.27}
+	if changed {
+		pr = map[string]float64{"N": 0.85, "L": 0.68, "H": 0.5}
+	}
+	prW, ok := pr[m["PR"]]
+	if !ok || (m["S"] != "U" && !changed) {
+		return 0, false
+	}
+
+	iss := 1 - (1-w["C"])*(1-w["I"])*(1-w["A"])
+	impact := 6.42 * iss
+	if changed {
+		impact = 7.52*(iss-0.029) - 3.25*math.Pow(iss-0.02, 15)
+	}
+	if impact <= 0 {
+		return 0, true
+	}
+
+	exploitability := 8.22 * w["AV"] * w["AC"] * prW * w["UI"]
+	if changed {
+		return roundUp(math.Min(1.08*(impact+exploitability), 10)), true
+	}
+	return roundUp(math.Min(impact+exploitability, 10)), true
+}
+
+// roundUp rounds up to one decimal as the CVSS spec defines it
+func roundUp(x float64) float64 {
+	i := int(math.Round(x * 100000))
+	if i%10000 == 0 {
+		return float64(i) / 100000
+	}
+	return float64(i/10000+1) / 10
+}
@@ -0,0 +1,88 @@
+package vuln
+
+import "testing"
+
+func TestCVSS3Score(t *testing.T) {
+	// Base scores as calculated by the FIRST/NVD calculators
+	cases := []struct {
+		vector string
+		want   float64
+	}{
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", 9.8},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H", 10.0},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:H", 7.5},
+		{"CVSS:3.1/AV:L/AC:L/PR:L/UI:N/S:U/C:H/I:H/A:H", 7.8},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:R/S:C/C:L/I:L/A:N", 6.1},
+		{"CVSS:3.1/AV:N/AC:L/PR:L/UI:N/S:C/C:L/I:L/A:N", 6.4},
+		{"CVSS:3.1/AV:N/AC:H/PR:N/UI:N/S:U/C:H/I:N/A:N", 5.9},
+		{"CVSS:3.0/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", 9.8},
+		{"CVSS:3.1/AV:P/AC:H/PR:H/UI:R/S:U/C:L/I:N/A:N", 1.6},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:N", 0},
+	}
+	for _, c := range cases {
+		got, ok := cvss3Score(c.vector)
+		if !ok || got != c.want {
+			t.Errorf("cvss3Score(%s) = %v, %v; want %v", c.vector, got, ok, c.want)
+		}
+	}
+
+	for _, bad := range []string{
+		"",
+		"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H",     // A missing
+		"CVSS:3.1/AV:X/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", // unknown value
+		"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/C:H/I:H/A:H",     // scope missing
+	} {
+		if _, ok := cvss3Score(bad); ok {
+			t.Errorf("cvss3Score(%q) accepted an invalid vector", bad)
+		}
+	}
+}
+
+func TestRoundUp(t *testing.T) {
+	cases := map[float64]float64{4.0: 4.0, 4.02: 4.1, 4.000001: 4.0, 9.75: 9.8, 0.01: 0.1}
+	for in, want := range cases {
+		if got := roundUp(in); got != want {
+			t.Errorf("roundUp(%v) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestScoreSeverity(t *testing.T) {
+	cases := map[float64]Severity{
+		10: Critical, 9.0: Critical, 8.9: High, 7.0: High, 6.9: Medium,
+		4.0: Medium, 3.9: Low, 0.1: Low, 0: Unknown,
+	}
+	for score, want := range cases {
+		if got := scoreSeverity(score); got != want {
+			t.Errorf("scoreSeverity(%v) = %s, want %s", score, got, want)
+		}
+	}
+}
+
+func TestSeverityOf(t *testing.T) {
+	var adv Advisory
+	adv.Severity = append(adv.Severity, struct {
+		Type  string `json:"type"`
+		Score string `json:"score"`
+	}{"CVSS_V3", "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:H"})
+	if got := severityOf(&adv); got != High {
+		t.Errorf("CVSS 7.5 rated %s, want high", got)
+	}
+
+	// The database's own rating wins over the score
+	adv.DatabaseSpecific.Severity = "MODERATE"
+	if got := severityOf(&adv); got != Medium {
+		t.Errorf("GHSA moderate rated %s, want medium", got)
+	}
+}
+
+func TestParseSeverity(t *testing.T) {
+	for name, want := range map[string]Severity{"critical": Critical, "HIGH": High, "moderate": Medium, "important": High, "low": Low} {
+		if got, err := ParseSeverity(name); err != nil || got != want {
+			t.Errorf("ParseSeverity(%q) = %s, %v; want %s", name, got, err, want)
+		}
+	}
+	if _, err := ParseSeverity("severe"); err == nil {
+		t.Error("ParseSeverity accepted an unknown level")
+	}
+}
@@ -0,0 +1,303 @@
+package vuln
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ---------------------------------------------------
+// VERSION ORDERING
+// ---------------------------------------------------
+
+// compareFunc orders two versions of one ecosystem: <0, 0 or >0
+type compareFunc func(a, b string) int
+
+// comparator picks the version ordering of an OSV ecosystem
+func comparator(ecosystem string) compareFunc {
+	switch {
+	case strings.HasPrefix(ecosystem, "Debian"), strings.HasPrefix(ecosystem, "Ubuntu"):
+		return compareDpkg
+	case ecosystem == "npm", ecosystem == "Go":
+		return compareSemver
+	case ecosystem == "PyPI":
+		return comparePyPI
+	}
+	// Alpine versions order well enough as alternating numeric and
+	// non-numeric runs
+	return compareNatural
+}
+
+var pep440 = regexp.MustCompile(`^v?(?:(\d+)!)?(\d+(?:\.\d+)*)` +
+	`(?:[-_.]?(a|b|c|rc|alpha|beta|pre|preview)[-_.]?(\d*))?` +
+	`(?:-(\d+)|[-_.]?(post|rev|r)[-_.]?(\d*))?` +
+	`(?:[-_.]?(dev)[-_.]?(\d*))?(?:\+.*)?$`)
+
+// pyVersion is a parsed PEP 440 version; the int fields use -1 for
+// "absent" where that sorts first and maxInt where it sorts last
+type pyVersion struct {
+	epoch   int
+	release []int
+	phase   int // pre-release phase: a=0, b=1, rc=2
+	pre     int
+	post    int
+	dev     int
+}
+
+const maxInt = int(^uint(0) >> 1)
+
+var pyPhases = map[string]int{"a": 0, "alpha": 0, "b": 1, "beta": 1, "c": 2, "rc": 2, "pre": 2, "preview": 2}
+
+func parsePyPI(v string) (pyVersion, bool) {
+	m := pep440.FindStringSubmatch(strings.ToLower(strings.TrimSpace(v)))
+	if m == nil {
+		return pyVersion{}, false
+	}
+	num := func(s string) int {
+		n, _ := strconv.Atoi(s) // an omitted number is 0
+		return n
+	}
+
+	p := pyVersion{epoch: num(m[1]), phase: maxInt, pre: maxInt, post: -1, dev: maxInt}
+	for _, part := range strings.Split(m[2], ".") {
+		p.release = append(p.release, num(part))
+	}
+	if m[3] != "" {
+		p.phase, p.pre = pyPhases[m[3]], num(m[4])
+	}
+	if m[5] != "" {
+		p.post = num(m[5])
+	} else if m[6] != "" {
+		p.post = num(m[7])
+	}
+	if m[8] != "" {
+		p.dev = num(m[9])
+		// 1.0.dev1 sorts before 1.0a1
+		if m[3] == "" && p.post < 0 {
+			p.phase, p.pre = -1, -1
+		}
+	}
+	return p, true
+}
+
+// comparePyPI orders PEP 440 versions: epoch, release, pre-, post- and
+// dev-release. Versions PEP 440 can't parse fall back to natural order.
+func comparePyPI(a, b string) int {
+	pa, okA := parsePyPI(a)
+	pb, okB := parsePyPI(b)
+	if !okA || !okB {
+		return compareNatural(a, b)
+	}
+
+	if c := pa.epoch - pb.epoch; c != 0 {
+		return c
+	}
+	// Missing release segments count as 0: 1.0 == 1.0.0
+	for i := 0; i < len(pa.release) || i < len(pb.release); i++ {
+		var x, y int
+		if i < len(pa.release) {
+			x = pa.release[i]
+		}
+		if i < len(pb.release) {
+			y = pb.release[i]
+		}
+		if x != y {
+			return cmpInt(x, y)
+		}
+	}
+	for _, pair := range [][2]int{{pa.phase, pb.phase}, {pa.pre, pb.pre}, {pa.post, pb.post}, {pa.dev, pb.dev}} {
+		if c := cmpInt(pair[0], pair[1]); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func cmpInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// compareSemver orders semantic versions; a leading "v" is ignored and a
+// prerelease sorts before its release
+func compareSemver(a, b string) int {
+	a, b = strings.TrimPrefix(a, "v"), strings.TrimPrefix(b, "v")
+	a, _, _ = strings.Cut(a, "+")
+	b, _, _ = strings.Cut(b, "+")
+	aCore, aPre, _ := strings.Cut(a, "-")
+	bCore, bPre, _ := strings.Cut(b, "-")
+
+	if c := compareNatural(aCore, bCore); c != 0 {
+		return c
+	}
+	switch {
+	case aPre == bPre:
+		return 0
+	case aPre == "":
+		return 1
+	case bPre == "":
+		return -1
+	}
+	return compareNatural(aPre, bPre)
+}
+
+// compareNatural compares digit runs numerically and everything else
+// lexically, e.g. 1.10 > 1.9 and 2.0rc1 < 2.0.1
+func compareNatural(a, b string) int {
+	for a != "" || b != "" {
+		aNum, aRest := splitRun(a)
+		bNum, bRest := splitRun(b)
+
+		if isDigits(aNum) && isDigits(bNum) {
+			if c := compareDigits(aNum, bNum); c != 0 {
+				return c
+			}
+		} else if aNum != bNum {
+			// A missing part sorts first (1.0 < 1.0.1), except before a
+			// pre-release marker (2.0rc1 < 2.0)
+			switch {
+			case aNum == "":
+				return -preRelease(bNum)
+			case bNum == "":
+				return preRelease(aNum)
+			case aNum < bNum:
+				return -1
+			}
+			return 1
+		}
+		a, b = aRest, bRest
+	}
+	return 0
+}
+
+// splitRun returns the leading run of digits or non-digits (separators
+// dropped) and the rest
+func splitRun(s string) (string, string) {
+	s = strings.TrimLeft(s, ".-_+")
+	if s == "" {
+		return "", ""
+	}
+	digit := s[0] >= '0' && s[0] <= '9'
+	i := 0
+	for i < len(s) && (s[i] >= '0' && s[i] <= '9') == digit && !strings.ContainsRune(".-_+", rune(s[i])) {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+// preRelease is -1 for pre-release markers (a, b, rc, alpha, beta, pre,
+// dev) and 1 for anything else (post, r, p, ...)
+func preRelease(run string) int {
+	switch strings.ToLower(run) {
+	case "a", "b", "c", "rc", "alpha", "beta", "pre", "preview", "dev":
+		return -1
+	}
+	return 1
+}
+
+func isDigits(s string) bool {
+	return s != "" && strings.Trim(s, "0123456789") == ""
+}
+
+func compareDigits(a, b string) int {
+	a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+	if len(a) != len(b) {
+		return len(a) - len(b)
+	}
+	return strings.Compare(a, b)
+}
+
+// compareDpkg implements Debian's version ordering:
+// [epoch:]upstream[-revision], where "~" sorts before everything
+func compareDpkg(a, b string) int {
+	aEpoch, aUp, aRev := splitDpkg(a)
+	bEpoch, bUp, bRev := splitDpkg(b)
+
+	if c := compareDigits(aEpoch, bEpoch); c != 0 {
+		return c
+	}
+	if c := compareDpkgPart(aUp, bUp); c != 0 {
+		return c
+	}
+	return compareDpkgPart(aRev, bRev)
+}
+
+func splitDpkg(v string) (epoch, upstream, revision string) {
+	epoch = "0"
+	if i := strings.Index(v, ":"); i >= 0 {
+		epoch, v = v[:i], v[i+1:]
+	}
+	if i := strings.LastIndex(v, "-"); i >= 0 {
+		return epoch, v[:i], v[i+1:]
+	}
+	return epoch, v, ""
+}
+
+// compareDpkgPart alternates non-digit runs (compared with dpkg's
+// character order) and digit runs (compared numerically)
+func compareDpkgPart(a, b string) int {
+	for a != "" || b != "" {
+		i, j := 0, 0
+		for i < len(a) && !isDigit(a[i]) {
+			i++
+		}
+		for j < len(b) && !isDigit(b[j]) {
+			j++
+		}
+		if c := compareDpkgText(a[:i], b[:j]); c != 0 {
+			return c
+		}
+		a, b = a[i:], b[j:]
+
+		i, j = 0, 0
+		for i < len(a) && isDigit(a[i]) {
+			i++
+		}
+		for j < len(b) && isDigit(b[j]) {
+			j++
+		}
+		if c := compareDigits(a[:i]+"0", b[:j]+"0"); c != 0 {
+			return c
+		}
+		a, b = a[i:], b[j:]
+	}
+	return 0
+}
+
+func compareDpkgText(a, b string) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var ca, cb byte
+		if i < len(a) {
+			ca = a[i]
+		}
+		if i < len(b) {
+			cb = b[i]
+		}
+		if oa, ob := dpkgOrder(ca), dpkgOrder(cb); oa != ob {
+			return oa - ob
+		}
+	}
+	return 0
+}
+
+// dpkgOrder: "~" first, then the end of the string, letters, other symbols
+func dpkgOrder(c byte) int {
+	switch {
+	case c == '~':
+		return -1
+	case c == 0:
+		return 0
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		return int(c)
+	}
+	return int(c) + 256
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,116 @@
+package vuln
+
+import "testing"
+
+// sign folds a comparison result to -1, 0 or 1
+func sign(c int) int {
+	switch {
+	case c < 0:
+		return -1
+	case c > 0:
+		return 1
+	}
+	return 0
+}
+
+type versionCase struct {
+	a, b string
+	want int
+}
+
+func checkOrder(t *testing.T, ecosystem string, cases []versionCase) {
+	t.Helper()
+	cmp := comparator(ecosystem)
+	for _, c := range cases {
+		if got := sign(cmp(c.a, c.b)); got != c.want {
+			t.Errorf("%s: compare(%q, %q) = %d, want %d", ecosystem, c.a, c.b, got, c.want)
+		}
+		// The ordering must be antisymmetric
+		if got := sign(cmp(c.b, c.a)); got != -c.want {
+			t.Errorf("%s: compare(%q, %q) = %d, want %d", ecosystem, c.b, c.a, got, -c.want)
+		}
+	}
+}
+
+func TestCompareDpkg(t *testing.T) {
+	checkOrder(t, "Debian:12", []versionCase{
+		{"1.2.3-1", "1.2.3-1", 0},
+		{"1.0", "1.0-1", -1},
+		{"0:1.0", "1.0", 0},
+		{"1:1.0", "2.0", 1},
+		{"1.01", "1.1", 0},
+		{"1.0~rc1", "1.0", -1},
+		{"1.0~rc1", "1.0~rc2", -1},
+		{"1.0~~", "1.0~", -1},
+		{"1.0", "1.0a", -1},
+		{"1.0a", "1.0+", -1},
+		{"1.9", "1.10", -1},
+		{"2.36-9+deb12u3", "2.36-9+deb12u4", -1},
+		{"2.36-9+deb12u9", "2.36-9+deb12u10", -1},
+		{"3.0.11-1~deb12u2", "3.0.11-1", -1},
+		{"7.88.1-10+deb12u5", "7.88.1-10+deb12u12", -1},
+		{"1.2.3-1ubuntu0.1", "1.2.3-1ubuntu0.22.04.1", -1},
+	})
+}
+
+func TestCompareSemver(t *testing.T) {
+	// The precedence example of the semver 2.0.0 spec, in order
+	spec := []string{
+		"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta",
+		"1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0",
+	}
+	var cases []versionCase
+	for i := 1; i < len(spec); i++ {
+		cases = append(cases, versionCase{spec[i-1], spec[i], -1})
+	}
+	cases = append(cases,
+		versionCase{"1.0.0", "1.0.0", 0},
+		versionCase{"1.0.0+build.5", "1.0.0", 0},
+		versionCase{"1.9.0", "1.10.0", -1},
+		versionCase{"2.0.0", "10.0.0", -1},
+		versionCase{"1.2.3", "1.2.4", -1},
+	)
+	checkOrder(t, "npm", cases)
+
+	checkOrder(t, "Go", []versionCase{
+		{"v1.2.3", "1.2.3", 0},
+		{"v0.0.0-20210101000000-abcdef123456", "v0.1.0", -1},
+		{"v1.20.0", "v1.3.0", 1},
+		{"v2.0.0-rc.1", "v2.0.0", -1},
+	})
+}
+
+func TestComparePyPI(t *testing.T) {
+	// PEP 440 ordering
+	order := []string{"1.0.dev1", "1.0a1", "1.0a2", "1.0b1", "1.0rc1", "1.0", "1.0.post1", "1.0.1", "1.1", "1.10"}
+	var cases []versionCase
+	for i := 1; i < len(order); i++ {
+		cases = append(cases, versionCase{order[i-1], order[i], -1})
+	}
+	cases = append(cases,
+		versionCase{"1.0", "1.0.0", 0},
+		versionCase{"2", "2.0.0", 0},
+		versionCase{"1.0RC1", "1.0rc1", 0},
+		versionCase{"2.0.0rc1", "2.0", -1},
+		versionCase{"10.0", "9.9", 1},
+		versionCase{"3.2.25", "4.2.11", -1},
+		versionCase{"1!1.0", "2.0", 1},
+		versionCase{"1.0.post", "1.0.post0", 0},
+		versionCase{"1.0-1", "1.0.post1", 0},
+		versionCase{"1.0+ubuntu1", "1.0", 0},
+		versionCase{"1.0rc1.dev1", "1.0rc1", -1},
+		versionCase{"1.0.post1.dev1", "1.0.post1", -1},
+		versionCase{"v1.2", "1.2", 0},
+	)
+	checkOrder(t, "PyPI", cases)
+}
+
+func TestCompareAlpine(t *testing.T) {
+	checkOrder(t, "Alpine:v3.19", []versionCase{
+		{"1.2.3-r0", "1.2.3-r1", -1},
+		{"3.1.4-r5", "3.1.4-r10", -1},
+		{"1.2.3", "1.2.3-r1", -1},
+		{"1.36.1-r15", "1.36.1-r15", 0},
+		{"3.0.12-r4", "3.1.4-r0", -1},
+	})
+}